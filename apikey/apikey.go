@@ -0,0 +1,226 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+const (
+	APIKEY_TYPE = "apikey"
+)
+
+// The placements of the API key.
+const (
+	InHeader = "header"
+	InQuery  = "query"
+	InCookie = "cookie"
+)
+
+// redacted replaces the API key in redacted URLs.
+const redacted = "REDACTED"
+
+var errNoHeaders = errors.New("an API key sent in the query cannot be provided as headers")
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 400.
+	Priority int
+
+	// Key is the API key.
+	Key string
+
+	// In is where the API key is placed in the request.
+	// Valid values: ""/"header", "query", "cookie"
+	In string
+
+	// Name is the name of the header, query parameter or cookie holding the
+	// API key. "" means default, which is "X-API-Key" for a header and
+	// "api_key" otherwise.
+	Name string
+}
+
+func (c *Config) IsActive() bool {
+	return c.Key != ""
+}
+
+type APIKey struct {
+	priority int
+	key      string
+	in       string
+	name     string
+	dispatch func(any)
+}
+
+func (a *APIKey) Priority() int {
+	return a.priority
+}
+
+// New creates a decorator that sends an API key. If the configuration is not
+// active, New returns nil after validating it.
+func New(config Config, dispatch func(any)) (*APIKey, error) {
+	in := config.In
+	if in == "" {
+		in = InHeader
+	}
+
+	name := config.Name
+	switch in {
+	case InHeader:
+		if name == "" {
+			name = "X-API-Key"
+		}
+		name = http.CanonicalHeaderKey(name)
+	case InQuery, InCookie:
+		if name == "" {
+			name = "api_key"
+		}
+	default:
+		return nil, fmt.Errorf("invalid In: %s", config.In)
+	}
+
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 400
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	return &APIKey{
+		priority: priority,
+		key:      config.Key,
+		in:       in,
+		name:     name,
+		dispatch: dispatch,
+	}, nil
+}
+
+// Decorate places the API key in an outgoing request.
+func (a *APIKey) Decorate(req *http.Request) error {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: APIKEY_TYPE,
+	}
+
+	switch a.in {
+	case InQuery:
+		req.URL.RawQuery = a.setParam(req.URL.RawQuery, a.key)
+	case InCookie:
+		a.Strip(req)
+		req.AddCookie(&http.Cookie{Name: a.name, Value: a.key})
+	default:
+		req.Header.Set(a.name, a.key)
+	}
+
+	a.dispatch(evnt)
+	return nil
+}
+
+// Headers returns the headers that Decorate sets on a request, for protocols
+// that do not send an *http.Request. An API key sent in the query cannot be
+// provided as headers.
+func (a *APIKey) Headers(context.Context) (http.Header, error) {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: APIKEY_TYPE,
+	}
+
+	var h http.Header
+	switch a.in {
+	case InQuery:
+		evnt.Err = errNoHeaders
+	case InCookie:
+		c := http.Cookie{Name: a.name, Value: a.key}
+		h = http.Header{"Cookie": {c.String()}}
+	default:
+		h = http.Header{a.name: {a.key}}
+	}
+
+	a.dispatch(evnt)
+	return h, evnt.Err
+}
+
+// Strip removes the API key from a request, such as one redirected to a host
+// the key must not be sent to.
+func (a *APIKey) Strip(req *http.Request) {
+	switch a.in {
+	case InQuery:
+		req.URL.RawQuery = a.setParam(req.URL.RawQuery, "")
+	case InCookie:
+		cookies := req.Cookies()
+		req.Header.Del("Cookie")
+		for _, c := range cookies {
+			if c.Name != a.name {
+				req.AddCookie(c)
+			}
+		}
+	default:
+		req.Header.Del(a.name)
+	}
+}
+
+// Redact returns the URL with the value of the API key query parameter
+// replaced, so it can be logged. The URL is returned as is if the API key is
+// not sent in the query.
+func (a *APIKey) Redact(u *url.URL) *url.URL {
+	if a.in != InQuery {
+		return u
+	}
+
+	var found bool
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		if a.isParam(pair) {
+			pairs[i] = url.QueryEscape(a.name) + "=" + redacted
+			found = true
+		}
+	}
+	if !found {
+		return u
+	}
+
+	u2 := *u
+	u2.RawQuery = strings.Join(pairs, "&")
+	return &u2
+}
+
+// setParam removes the API key parameter from the raw query and appends it
+// with the value, unless the value is empty. The other parameters are left
+// exactly as they are.
+func (a *APIKey) setParam(rawQuery, value string) string {
+	var pairs []string
+	if rawQuery != "" {
+		for _, pair := range strings.Split(rawQuery, "&") {
+			if !a.isParam(pair) {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	if value != "" {
+		pairs = append(pairs, url.QueryEscape(a.name)+"="+url.QueryEscape(value))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// isParam reports whether a name=value pair of a raw query is the API key.
+func (a *APIKey) isParam(pair string) bool {
+	name, _, _ := strings.Cut(pair, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+	return name == a.name
+}
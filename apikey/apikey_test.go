@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		description    string
+		config         Config
+		expectPriority int
+		expectHeader   http.Header
+		expectURL      string
+		expectCookie   string
+		expectRedacted string
+		expectNil      bool
+		expectError    bool
+	}{
+		{
+			description: "empty configuration means no auth",
+			expectNil:   true,
+		}, {
+			description: "an invalid placement",
+			config: Config{
+				In: "body",
+			},
+			expectError: true,
+		}, {
+			description: "a header",
+			config: Config{
+				Key: "secret",
+			},
+			expectPriority: 400,
+			expectHeader:   http.Header{"X-Api-Key": {"secret"}},
+			expectURL:      "https://example.com/path?a=1",
+			expectRedacted: "https://example.com/path?a=1",
+		}, {
+			description: "a named header with a priority",
+			config: Config{
+				Priority: 12,
+				Key:      "secret",
+				In:       InHeader,
+				Name:     "x-custom-key",
+			},
+			expectPriority: 12,
+			expectHeader:   http.Header{"X-Custom-Key": {"secret"}},
+			expectURL:      "https://example.com/path?a=1",
+			expectRedacted: "https://example.com/path?a=1",
+		}, {
+			description: "a query parameter",
+			config: Config{
+				Key: "secret",
+				In:  InQuery,
+			},
+			expectPriority: 400,
+			expectURL:      "https://example.com/path?a=1&api_key=secret",
+			expectRedacted: "https://example.com/path?a=1&api_key=REDACTED",
+		}, {
+			description: "a cookie",
+			config: Config{
+				Key:  "secret",
+				In:   InCookie,
+				Name: "session",
+			},
+			expectPriority: 400,
+			expectHeader:   http.Header{"Cookie": {"session=secret"}},
+			expectURL:      "https://example.com/path?a=1",
+			expectCookie:   "other=1; session=secret",
+			expectRedacted: "https://example.com/path?a=1",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var decorated []events.DecorateEvent
+			got, err := New(tc.config, func(evnt any) {
+				if e, ok := evnt.(events.DecorateEvent); ok {
+					decorated = append(decorated, e)
+					return
+				}
+				assert.Fail("unexpected event type")
+			})
+
+			if tc.expectError {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			if tc.expectNil {
+				assert.Nil(got)
+				return
+			}
+			assert.Equal(tc.expectPriority, got.Priority())
+
+			req, err := http.NewRequest("GET", "https://example.com/path?a=1", nil)
+			require.NoError(err)
+			req.AddCookie(&http.Cookie{Name: "other", Value: "1"})
+
+			// Decorating twice must not duplicate the key.
+			require.NoError(got.Decorate(req))
+			require.NoError(got.Decorate(req))
+
+			assert.Equal(tc.expectURL, req.URL.String())
+			if tc.config.In == InCookie {
+				assert.Equal(tc.expectCookie, req.Header.Get("Cookie"))
+			} else {
+				for k := range tc.expectHeader {
+					assert.Equal(tc.expectHeader.Get(k), req.Header.Get(k))
+				}
+			}
+			assert.Equal(tc.expectRedacted, got.Redact(req.URL).String())
+			assert.Equal(tc.expectURL, req.URL.String(), "Redact must not modify the URL")
+
+			h, err := got.Headers(context.Background())
+			if tc.config.In == InQuery {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tc.expectHeader, h)
+			}
+
+			require.Len(decorated, 3)
+			for _, e := range decorated {
+				assert.Equal(APIKEY_TYPE, e.Type)
+			}
+
+			got.Strip(req)
+			assert.Equal("https://example.com/path?a=1", req.URL.String())
+			assert.Equal("other=1", req.Header.Get("Cookie"))
+			for k := range tc.expectHeader {
+				if k != "Cookie" {
+					assert.Empty(req.Header.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	got, err := New(Config{Key: "secret", In: InQuery}, nil)
+	require.NoError(t, err)
+
+	u, err := url.Parse("https://example.com/path")
+	require.NoError(t, err)
+	assert.Same(t, u, got.Redact(u))
+}
+
+func TestQueryPreserved(t *testing.T) {
+	got, err := New(Config{Key: "s&cret", In: InQuery}, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "https://example.com/path?z=2&a=b%20c;d=e&api_key=old&x", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, got.Decorate(req))
+	assert.Equal(t, "z=2&a=b%20c;d=e&x&api_key=s%26cret", req.URL.RawQuery)
+	assert.Equal(t, "z=2&a=b%20c;d=e&x&api_key=REDACTED", got.Redact(req.URL).RawQuery)
+
+	got.Strip(req)
+	assert.Equal(t, "z=2&a=b%20c;d=e&x", req.URL.RawQuery)
+}
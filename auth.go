@@ -4,29 +4,82 @@
 package vouch
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"time"
 
 	"github.com/xmidt-org/eventor"
+	"github.com/xmidt-org/vouch/apikey"
 	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/bearer"
+	"github.com/xmidt-org/vouch/digest"
 	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/httpsig"
 	"github.com/xmidt-org/vouch/oauth"
+	"github.com/xmidt-org/vouch/serviceaccount"
+	"github.com/xmidt-org/vouch/sigv4"
 )
 
 // Config is the configuration for the Vouch authentication system. It contains
-// the configuration for both basic and OAuth authentication methods.
+// the configuration for each of the authentication methods.
 //
 // Empty sub-structures are valid and disable the corresponding
 // authentication method. For example, if Basic is empty, basic authentication
 // is disabled. If OAuth is empty, OAuth authentication is disabled.
-// If both Basic and OAuth are empty, no authentication decoration is performed.
+// If all of them are empty, no authentication decoration is performed.
 type Config struct {
 	// Basic is the configuration for basic authentication.
 	Basic basic.Config
 
 	// OAuth is the configuration for OAuth authentication.
 	OAuth oauth.Config
+
+	// Bearer is the configuration for static bearer token authentication.
+	Bearer bearer.Config
+
+	// APIKey is the configuration for API key authentication.
+	APIKey apikey.Config
+
+	// SigV4 is the configuration for AWS Signature Version 4 signing.
+	SigV4 sigv4.Config
+
+	// HTTPSig is the configuration for HTTP Message Signatures.
+	HTTPSig httpsig.Config
+
+	// Digest is the configuration for Digest authentication. Digest
+	// credentials are only sent once the server has issued a challenge, so
+	// requests must be sent using a Transport.
+	Digest digest.Config
+
+	// ServiceAccount is the configuration for sending a Kubernetes projected
+	// service account token.
+	ServiceAccount serviceaccount.Config
+
+	// Proxy is the configuration for basic authentication with an egress
+	// proxy. These credentials are sent in the Proxy-Authorization header of
+	// plaintext requests that go through a proxy, in addition to the
+	// credentials for the destination; see Vouch.ProxyConnectHeader for https
+	// destinations. A plaintext proxy must be listed in InsecureHosts.
+	Proxy basic.Config
+
+	// Routes selects the credentials to use based on the destination of the
+	// request. Each request is decorated using the first route that matches
+	// it. Requests that match no route are decorated using Basic and OAuth
+	// above; if neither is configured, Decorate returns ErrNoRoute.
+	Routes []Route
+
+	// InsecureHosts lists the hosts that credentials may be sent to over
+	// plaintext HTTP, such as loopback or test hosts. Entries may include a
+	// port to limit the match to that port, and "*" allows any host. By
+	// default credentials are only sent over https. This applies to the
+	// OAuth TokenURL as well as to the decorated requests.
+	InsecureHosts []string
+
+	// RedirectHosts lists the hosts, in addition to the host of the original
+	// request, that credentials may follow a redirect to. Entries may include
+	// a port to limit the match to that port.
+	RedirectHosts []string
 }
 
 type decorator interface {
@@ -34,17 +87,52 @@ type decorator interface {
 	Priority() int
 }
 
+var errNoHeaders = errors.New("no credentials can be provided as headers")
+
+// headerer is implemented by decorators that can provide their credentials
+// as headers without a request.
+type headerer interface {
+	Headers(context.Context) (http.Header, error)
+}
+
+// challenger is implemented by decorators that can recover from an
+// authentication challenge returned by the server. Challenge returns true if
+// the request should be decorated again and retried.
+type challenger interface {
+	Challenge(*http.Request, *http.Response) bool
+}
+
+// observer is implemented by decorators that keep state sent by the server
+// with any response to a decorated request, such as a DPoP nonce.
+type observer interface {
+	Observe(*http.Request, *http.Response)
+}
+
 // Vouch is the main struct that holds the authentication methods and event
 // listeners. It contains the OAuth and Basic authentication methods, as well as
 // the event listeners for fetch and decorate events.
 type Vouch struct {
-	oauth *oauth.OAuth
-	basic *basic.Basic
+	oauth          *oauth.OAuth
+	basic          *basic.Basic
+	bearer         *bearer.Bearer
+	apikey         *apikey.APIKey
+	sigv4          *sigv4.SigV4
+	httpsig        *httpsig.Signer
+	digest         *digest.Digest
+	serviceAccount *serviceaccount.ServiceAccount
+	proxy          *basic.Basic
 
 	fetchListeners    eventor.Eventor[events.FetchEventListener]
 	decorateListeners eventor.Eventor[events.DecorateEventListener]
+	redirectListeners eventor.Eventor[events.RedirectEventListener]
 
-	decorators []decorator
+	decorators    []decorator
+	proxies       []decorator
+	routes        []route
+	redactors     []redactor
+	insecureHosts []string
+	redirectHosts []string
+	proxyFor      proxyFunc
 }
 
 // Option is a function that configures the Auth instance.
@@ -57,12 +145,25 @@ func New(cfg Config, opts ...Option) (*Vouch, error) {
 	var auth Vouch
 
 	defaults := []Option{
+		WithProxyFunc(http.ProxyFromEnvironment),
+		handleInsecureHosts(cfg.InsecureHosts),
+		checkTokenURLs(cfg),
 		handleBasic(cfg.Basic),
 		handleOAuth(cfg.OAuth),
+		handleBearer(cfg.Bearer),
+		handleAPIKey(cfg.APIKey),
+		handleSigV4(cfg.SigV4),
+		handleHTTPSig(cfg.HTTPSig),
+		handleDigest(cfg.Digest),
+		handleServiceAccount(cfg.ServiceAccount),
+		handleProxy(cfg.Proxy),
+		handleRoutes(cfg.Routes),
+		handleRedirectHosts(cfg.RedirectHosts),
 	}
 
 	finalize := []Option{
 		setupDecorators(),
+		setupRedactors(),
 	}
 
 	all := append(defaults, opts...)
@@ -87,16 +188,48 @@ func (a *Vouch) AddDecorateEventListener(listener events.DecorateEventListener)
 	return a.decorateListeners.Add(listener)
 }
 
+// AddRedirectEventListener adds a listener for redirect events.
+func (a *Vouch) AddRedirectEventListener(listener events.RedirectEventListener) (cancel func()) {
+	return a.redirectListeners.Add(listener)
+}
+
 // Decorate decorates the request with the appropriate authentication method.
 // It tries each decorator in order of priority until one succeeds or all fail.
+// Credentials are only attached to https requests unless the host is listed in
+// InsecureHosts; otherwise an *InsecureTransportError is returned. The
+// request's context bounds how long Decorate waits for credentials, such
+// as an OAuth token, to be fetched.
+//
+// Credentials for an egress proxy are added to plaintext requests sent
+// through a proxy, in addition to the credentials for the destination. The
+// proxy of the request is found as set by WithProxyFunc; a Transport uses the
+// Proxy of its Base instead.
 func (a *Vouch) Decorate(req *http.Request) error {
+	return a.decorate(req, a.proxyFor)
+}
+
+// decorate decorates the request, which is sent through the given proxy.
+func (a *Vouch) decorate(req *http.Request, proxy proxyFunc) error {
 	evnt := events.DecorateEvent{
 		At:   time.Now(),
 		Type: "none",
 	}
 
-	errs := make([]error, 0, len(a.decorators))
-	for _, decorator := range a.decorators {
+	decorators, proxies, err := a.decoratorsFor(req)
+	if err == nil && len(decorators) > 0 {
+		err = a.checkTransport(req.URL)
+	}
+	if err == nil {
+		err = a.decorateProxy(req, proxies, proxy)
+	}
+	if err != nil {
+		evnt.Err = err
+		a.dispatch(evnt)
+		return err
+	}
+
+	errs := make([]error, 0, len(decorators))
+	for _, decorator := range decorators {
 		err := decorator.Decorate(req)
 		if err == nil {
 			return nil
@@ -104,13 +237,83 @@ func (a *Vouch) Decorate(req *http.Request) error {
 		errs = append(errs, err)
 	}
 
-	err := errors.Join(errs...)
+	err = errors.Join(errs...)
 	evnt.Duration = time.Since(evnt.At)
 	evnt.Err = err
 	a.dispatch(evnt)
 	return err
 }
 
+// Headers returns the headers the default credentials would set on a request,
+// for protocols such as WebSockets or gRPC that do not send an *http.Request.
+// Routes are not consulted, since there is no destination to match, and the
+// caller is responsible for only sending the headers over TLS.
+//
+// Like Decorate, it tries each decorator in order of priority until one
+// succeeds or all fail. Decorators that need the request to compute their
+// credentials are skipped. The context bounds how long Headers waits for
+// credentials to be fetched.
+func (a *Vouch) Headers(ctx context.Context) (http.Header, error) {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: "none",
+	}
+
+	errs := make([]error, 0, len(a.decorators))
+	for _, d := range a.decorators {
+		h, ok := d.(headerer)
+		if !ok {
+			continue
+		}
+		headers, err := h.Headers(ctx)
+		if err == nil {
+			return headers, nil
+		}
+		errs = append(errs, err)
+	}
+
+	switch {
+	case len(errs) > 0:
+	case len(a.decorators) > 0:
+		errs = append(errs, errNoHeaders)
+	case len(a.routes) > 0:
+		errs = append(errs, ErrNoRoute)
+	default:
+		return http.Header{}, nil
+	}
+
+	err := errors.Join(errs...)
+	evnt.Duration = time.Since(evnt.At)
+	evnt.Err = err
+	a.dispatch(evnt)
+	return nil, err
+}
+
+// challenge offers the response to every decorator that can handle an
+// authentication challenge and reports whether any of them asked for a retry.
+func (a *Vouch) challenge(req *http.Request, resp *http.Response) bool {
+	decorators, _, _ := a.decoratorsFor(req)
+
+	var retry bool
+	for _, d := range decorators {
+		if c, ok := d.(challenger); ok && c.Challenge(req, resp) {
+			retry = true
+		}
+	}
+	return retry
+}
+
+// observe offers a response that is not retried to every decorator that
+// keeps state sent by the server.
+func (a *Vouch) observe(req *http.Request, resp *http.Response) {
+	decorators, _, _ := a.decoratorsFor(req)
+	for _, d := range decorators {
+		if o, ok := d.(observer); ok {
+			o.Observe(req, resp)
+		}
+	}
+}
+
 // dispatch dispatches the event to the listeners and returns the error that
 // should be returned by the caller.
 func (a *Vouch) dispatch(event any) {
@@ -123,5 +326,9 @@ func (a *Vouch) dispatch(event any) {
 		a.decorateListeners.Visit(func(listener events.DecorateEventListener) {
 			listener.OnDecorateEvent(event)
 		})
+	case events.RedirectEvent:
+		a.redirectListeners.Visit(func(listener events.RedirectEventListener) {
+			listener.OnRedirectEvent(event)
+		})
 	}
 }
@@ -4,13 +4,18 @@
 package vouch
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
 
 	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/bearer"
 	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/httpsig"
 	"github.com/xmidt-org/vouch/oauth"
+	"github.com/xmidt-org/vouch/serviceaccount"
+	"github.com/xmidt-org/vouch/sigv4"
 )
 
 func TestVouch_New(t *testing.T) {
@@ -49,6 +54,43 @@ func TestVouch_New(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			description: "Invalid Bearer configuration",
+			config: Config{
+				Bearer: bearer.Config{
+					TokenFile: "/nonexistent/token",
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "Invalid ServiceAccount configuration",
+			config: Config{
+				ServiceAccount: serviceaccount.Config{
+					TokenFile: "/nonexistent/token",
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "Invalid HTTPSig configuration",
+			config: Config{
+				HTTPSig: httpsig.Config{
+					Secret: "secret",
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "Invalid SigV4 configuration",
+			config: Config{
+				SigV4: sigv4.Config{
+					AccessKeyID:     "id",
+					SecretAccessKey: "secret",
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -123,6 +165,88 @@ func TestVouch_Decorate(t *testing.T) {
 	}
 }
 
+func TestVouch_Headers(t *testing.T) {
+	tests := []struct {
+		description string
+		decorators  []decorator
+		routes      []route
+		expectAuth  string
+		expectError bool
+	}{
+		{
+			description: "No decorators",
+		},
+		{
+			description: "First decorator that provides headers",
+			decorators: []decorator{
+				mockDecorator{},
+				mockHeaderer{
+					headersFunc: func(context.Context) (http.Header, error) {
+						return nil, errors.New("failed")
+					},
+				},
+				mockHeaderer{
+					headersFunc: func(context.Context) (http.Header, error) {
+						return http.Header{"Authorization": {"Bearer token"}}, nil
+					},
+				},
+			},
+			expectAuth: "Bearer token",
+		},
+		{
+			description: "All decorators fail",
+			decorators: []decorator{
+				mockHeaderer{
+					headersFunc: func(context.Context) (http.Header, error) {
+						return nil, errors.New("failed")
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "No decorator provides headers",
+			decorators:  []decorator{mockDecorator{}},
+			expectError: true,
+		},
+		{
+			description: "Only routes",
+			routes:      []route{{}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var got []events.DecorateEvent
+			v := &Vouch{
+				decorators: tc.decorators,
+				routes:     tc.routes,
+			}
+			v.AddDecorateEventListener(events.DecorateEventListenerFunc(func(e events.DecorateEvent) {
+				got = append(got, e)
+			}))
+
+			h, err := v.Headers(context.Background())
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				if len(got) != 1 || got[0].Err == nil {
+					t.Errorf("expected a decorate event with the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("did not expect an error but got: %v", err)
+			}
+			if h.Get("Authorization") != tc.expectAuth {
+				t.Errorf("expected Authorization %q but got %q", tc.expectAuth, h.Get("Authorization"))
+			}
+		})
+	}
+}
+
 func TestVouch_AddFetchEventListener(t *testing.T) {
 	listenerCalled := false
 	listener := events.FetchEventListenerFunc(func(event events.FetchEvent) {
@@ -188,3 +312,12 @@ func (m mockDecorator) Decorate(req *http.Request) error {
 func (m mockDecorator) Priority() int {
 	return 0
 }
+
+type mockHeaderer struct {
+	mockDecorator
+	headersFunc func(context.Context) (http.Header, error)
+}
+
+func (m mockHeaderer) Headers(ctx context.Context) (http.Header, error) {
+	return m.headersFunc(ctx)
+}
@@ -4,6 +4,8 @@
 package basic
 
 import (
+	"context"
+	"encoding/base64"
 	"net/http"
 	"time"
 
@@ -24,6 +26,10 @@ type Config struct {
 
 	// Password is the password for basic authentication.
 	Password string
+
+	// Proxy sends the credentials to an authenticating egress proxy using the
+	// Proxy-Authorization header instead of the Authorization header.
+	Proxy bool
 }
 
 func (c *Config) IsActive() bool {
@@ -34,6 +40,7 @@ type Basic struct {
 	priority int
 	username string
 	password string
+	header   string
 	dispatch func(any)
 }
 
@@ -41,6 +48,11 @@ func (b *Basic) Priority() int {
 	return b.priority
 }
 
+// Proxy reports whether the credentials are for an egress proxy.
+func (b *Basic) Proxy() bool {
+	return b.header == "Proxy-Authorization"
+}
+
 func New(config Config, dispatch func(any)) *Basic {
 	if !config.IsActive() {
 		return nil
@@ -53,20 +65,41 @@ func New(config Config, dispatch func(any)) *Basic {
 	if dispatch == nil {
 		dispatch = func(any) {}
 	}
+	header := "Authorization"
+	if config.Proxy {
+		header = "Proxy-Authorization"
+	}
 	return &Basic{
 		username: config.Username,
 		password: config.Password,
 		priority: priority,
+		header:   header,
 		dispatch: dispatch,
 	}
 }
 
 func (b *Basic) Decorate(req *http.Request) error {
+	h, err := b.Headers(req.Context())
+	if err != nil {
+		return err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	return nil
+}
+
+// Headers returns the headers that Decorate sets on a request, for protocols
+// that do not send an *http.Request.
+func (b *Basic) Headers(context.Context) (http.Header, error) {
 	evnt := events.DecorateEvent{
 		At:   time.Now(),
 		Type: BASIC_TYPE,
 	}
-	req.SetBasicAuth(b.username, b.password)
+	auth := base64.StdEncoding.EncodeToString([]byte(b.username + ":" + b.password))
+	h := http.Header{
+		b.header: {"Basic " + auth},
+	}
 	b.dispatch(evnt)
-	return nil
+	return h, nil
 }
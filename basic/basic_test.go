@@ -4,6 +4,7 @@
 package basic
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -19,6 +20,7 @@ func TestNew(t *testing.T) {
 		want        Basic
 		withFn      bool
 		header      string
+		proxy       bool
 	}{
 		{
 			description: "empty configuration means no auth",
@@ -48,6 +50,20 @@ func TestNew(t *testing.T) {
 			},
 			header: "Basic dXNlcjpwYXNz",
 			withFn: true,
+		}, {
+			description: "a configuration for a proxy",
+			config: Config{
+				Username: "user",
+				Password: "pass",
+				Proxy:    true,
+			},
+			want: Basic{
+				priority: 300,
+				username: "user",
+				password: "pass",
+			},
+			header: "Basic dXNlcjpwYXNz",
+			proxy:  true,
 		},
 	}
 	for _, tc := range tests {
@@ -89,7 +105,17 @@ func TestNew(t *testing.T) {
 			err = got.Decorate(req)
 			assert.NoError(err)
 
-			assert.Equal(tc.header, req.Header.Get("Authorization"))
+			header, other := "Authorization", "Proxy-Authorization"
+			if tc.proxy {
+				header, other = other, header
+			}
+			assert.Equal(tc.header, req.Header.Get(header))
+			assert.Empty(req.Header.Get(other))
+			assert.Equal(tc.proxy, got.Proxy())
+
+			h, err := got.Headers(context.Background())
+			assert.NoError(err)
+			assert.Equal(tc.header, h.Get(header))
 
 			assert.Equal(want.priority, got.Priority())
 		})
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package bearer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+const (
+	BEARER_TYPE = "bearer"
+)
+
+var errEmptyToken = errors.New("bearer token is empty")
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 500.
+	Priority int
+
+	// Token is the long-lived token to send.
+	Token string
+
+	// TokenFile is the path of a file holding the token, used when Token is
+	// empty. Leading and trailing whitespace is ignored.
+	TokenFile string
+
+	// Scheme is the authorization scheme sent with the token.
+	// "" means default, which is "Bearer".
+	Scheme string
+}
+
+func (c *Config) IsActive() bool {
+	return c.Token != "" || c.TokenFile != ""
+}
+
+type Bearer struct {
+	priority int
+	auth     string
+	dispatch func(any)
+}
+
+func (b *Bearer) Priority() int {
+	return b.priority
+}
+
+// New creates a decorator that sends a static token. If the configuration is
+// not active, New returns nil.
+func New(config Config, dispatch func(any)) (*Bearer, error) {
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	token := config.Token
+	if token == "" {
+		data, err := os.ReadFile(config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TokenFile: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return nil, errEmptyToken
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 500
+	}
+	scheme := config.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	return &Bearer{
+		priority: priority,
+		auth:     scheme + " " + token,
+		dispatch: dispatch,
+	}, nil
+}
+
+// Decorate sets the Authorization header on an outgoing request.
+func (b *Bearer) Decorate(req *http.Request) error {
+	h, err := b.Headers(req.Context())
+	if err != nil {
+		return err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	return nil
+}
+
+// Headers returns the headers that Decorate sets on a request, for protocols
+// that do not send an *http.Request.
+func (b *Bearer) Headers(context.Context) (http.Header, error) {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: BEARER_TYPE,
+	}
+	h := http.Header{
+		"Authorization": {b.auth},
+	}
+	b.dispatch(evnt)
+	return h, nil
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package bearer
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("  file-token\n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0600))
+
+	tests := []struct {
+		description    string
+		config         Config
+		expectPriority int
+		header         string
+		expectNil      bool
+		expectError    bool
+	}{
+		{
+			description: "empty configuration means no auth",
+			expectNil:   true,
+		}, {
+			description: "a simple configuration",
+			config: Config{
+				Token: "token",
+			},
+			expectPriority: 500,
+			header:         "Bearer token",
+		}, {
+			description: "a configuration with a scheme and priority",
+			config: Config{
+				Priority: 12,
+				Token:    "token",
+				Scheme:   "Token",
+			},
+			expectPriority: 12,
+			header:         "Token token",
+		}, {
+			description: "a token file",
+			config: Config{
+				TokenFile: tokenFile,
+			},
+			expectPriority: 500,
+			header:         "Bearer file-token",
+		}, {
+			description: "the token wins over the token file",
+			config: Config{
+				Token:     "token",
+				TokenFile: tokenFile,
+			},
+			expectPriority: 500,
+			header:         "Bearer token",
+		}, {
+			description: "a missing token file",
+			config: Config{
+				TokenFile: filepath.Join(dir, "missing"),
+			},
+			expectError: true,
+		}, {
+			description: "an empty token file",
+			config: Config{
+				TokenFile: emptyFile,
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var decorated []events.DecorateEvent
+			got, err := New(tc.config, func(evnt any) {
+				if e, ok := evnt.(events.DecorateEvent); ok {
+					decorated = append(decorated, e)
+					return
+				}
+				assert.Fail("unexpected event type")
+			})
+
+			if tc.expectError {
+				assert.Error(err)
+				assert.Nil(got)
+				return
+			}
+			require.NoError(err)
+			if tc.expectNil {
+				assert.Nil(got)
+				return
+			}
+
+			assert.Equal(tc.expectPriority, got.Priority())
+
+			req, err := http.NewRequest("GET", "https://example.com", nil)
+			require.NoError(err)
+			require.NoError(got.Decorate(req))
+			assert.Equal(tc.header, req.Header.Get("Authorization"))
+
+			h, err := got.Headers(context.Background())
+			require.NoError(err)
+			assert.Equal(tc.header, h.Get("Authorization"))
+
+			require.Len(decorated, 2)
+			for _, e := range decorated {
+				assert.NotZero(e.At)
+				assert.Equal(BEARER_TYPE, e.Type)
+				assert.NoError(e.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// vouch-proxy is a reverse proxy that adds vouch credentials to the requests
+// it sends upstream, for tools that cannot authenticate themselves.
+//
+// The proxy is configured with a JSON file:
+//
+//	{
+//	  "Listen": "127.0.0.1:8080",
+//	  "Routes": [
+//	    {
+//	      "Prefix": "/api/",
+//	      "Upstream": "https://api.example.com",
+//	      "Vouch": {
+//	        "OAuth": {
+//	          "ClientID": "client",
+//	          "ClientSecret": "secret",
+//	          "TokenURL": "https://auth.example.com/token"
+//	        }
+//	      }
+//	    }
+//	  ]
+//	}
+//
+// The readiness of the proxy is reported at /readyz.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/xmidt-org/vouch"
+	"github.com/xmidt-org/vouch/events"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("vouch-proxy", flag.ContinueOnError)
+	file := fs.String("config", "vouch-proxy.json", "the configuration file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*file)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	p, err := newProxy(cfg, logger,
+		vouch.WithFetchEventListener(events.FetchEventListenerFunc(func(e events.FetchEvent) {
+			if e.Err != nil {
+				logger.Error("fetch failed", "type", e.Type, "err", e.Err)
+				return
+			}
+			logger.Info("fetched", "type", e.Type, "expiration", e.Expiration)
+		})),
+		vouch.WithDecorateEventListener(events.DecorateEventListenerFunc(func(e events.DecorateEvent) {
+			if e.Err != nil {
+				logger.Error("decorate failed", "type", e.Type, "err", e.Err)
+			}
+		})),
+	)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return serve(ctx, &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           p,
+		ReadHeaderTimeout: 10 * time.Second,
+	}, logger)
+}
+
+// serve runs the server until it fails or the context is canceled, and then
+// shuts it down gracefully.
+func serve(ctx context.Context, srv *http.Server, logger *slog.Logger) error {
+	errs := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func loadConfig(file string) (Config, error) {
+	cfg := Config{
+		Listen: "127.0.0.1:8080",
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch"
+)
+
+// readyPath is the path of the readiness endpoint.
+const readyPath = "/readyz"
+
+// readyTimeout bounds how long the readiness check waits for credentials.
+const readyTimeout = 5 * time.Second
+
+// Config is the configuration of the proxy.
+type Config struct {
+	// Listen is the address the proxy listens on. Defaults to
+	// "127.0.0.1:8080", so only local clients can use the credentials of
+	// the routes.
+	Listen string
+
+	// Routes are the upstreams the proxy sends requests to.
+	Routes []Route
+}
+
+// Route sends the requests matching a path prefix to an upstream.
+type Route struct {
+	// Prefix is the path prefix of the requests sent to the upstream, using
+	// the http.ServeMux pattern syntax. For example "/api/". Each route
+	// needs its own Prefix, which may not be the readiness path "/readyz".
+	Prefix string
+
+	// StripPrefix removes the Prefix from the request path before it is
+	// sent to the upstream. The Prefix must then be a plain path, without a
+	// method, host or wildcards.
+	StripPrefix bool
+
+	// Upstream is the absolute URL of the upstream. The request path is
+	// appended to the path of the Upstream.
+	Upstream string
+
+	// Vouch is the configuration of the credentials sent to the upstream.
+	Vouch vouch.Config
+}
+
+type upstream struct {
+	url   *url.URL
+	vouch *vouch.Vouch
+}
+
+type proxy struct {
+	mux       *http.ServeMux
+	logger    *slog.Logger
+	upstreams []upstream
+}
+
+// newProxy creates the handler of the proxy. The options are applied to the
+// vouch.Vouch of every route.
+func newProxy(cfg Config, logger *slog.Logger, opts ...vouch.Option) (*proxy, error) {
+	if len(cfg.Routes) == 0 {
+		return nil, errors.New("no routes configured")
+	}
+
+	p := proxy{
+		mux:    http.NewServeMux(),
+		logger: logger,
+	}
+	p.mux.HandleFunc(readyPath, p.ready)
+
+	prefixes := map[string]bool{}
+	for i, r := range cfg.Routes {
+		switch {
+		case r.Prefix == "":
+			return nil, fmt.Errorf("route %d: no Prefix", i)
+		case r.Prefix == readyPath:
+			return nil, fmt.Errorf("route %s: the Prefix is the readiness path", r.Prefix)
+		case prefixes[r.Prefix]:
+			return nil, fmt.Errorf("route %s: duplicate Prefix", r.Prefix)
+		case r.StripPrefix && !isPlainPath(r.Prefix):
+			return nil, fmt.Errorf("route %s: StripPrefix needs a Prefix that is a plain path", r.Prefix)
+		}
+		prefixes[r.Prefix] = true
+
+		u, err := url.Parse(r.Upstream)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", r.Prefix, err)
+		}
+		if !u.IsAbs() || u.Host == "" {
+			return nil, fmt.Errorf("route %s: Upstream must be an absolute URL with a host", r.Prefix)
+		}
+
+		v, err := vouch.New(r.Vouch, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", r.Prefix, err)
+		}
+
+		var h http.Handler = &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(u)
+				pr.SetXForwarded()
+				// The inbound credentials are never passed upstream.
+				pr.Out.Header.Del("Authorization")
+			},
+			// The client's transport presents the TLS client certificate
+			// of the route, if any.
+			Transport: v.Client(nil).Transport,
+		}
+		if r.StripPrefix {
+			h = http.StripPrefix(strings.TrimSuffix(r.Prefix, "/"), h)
+		}
+
+		if err := handle(p.mux, r.Prefix, h); err != nil {
+			return nil, fmt.Errorf("route %s: %w", r.Prefix, err)
+		}
+		p.upstreams = append(p.upstreams, upstream{
+			url:   u,
+			vouch: v,
+		})
+	}
+
+	return &p, nil
+}
+
+// isPlainPath reports whether the http.ServeMux pattern only has a path.
+func isPlainPath(pattern string) bool {
+	return strings.HasPrefix(pattern, "/") && !strings.ContainsAny(pattern, " \t{}")
+}
+
+// handle registers the handler, returning the error http.ServeMux panics
+// with for invalid or conflicting patterns.
+func handle(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+// ready answers 200 when credentials are available for every upstream, and
+// 503 otherwise. The errors are logged rather than returned, since they may
+// describe the credentials.
+func (p *proxy) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, u := range p.upstreams {
+		if err := u.ready(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", u.url.Host, err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := errors.Join(errs...); err != nil {
+		p.logger.Error("not ready", "err", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, http.StatusText(http.StatusServiceUnavailable))
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// ready checks that the credentials for the upstream can be obtained.
+func (u upstream) ready(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url.String(), nil)
+	if err != nil {
+		return err
+	}
+	return u.vouch.Decorate(req)
+}
@@ -0,0 +1,299 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch"
+	"github.com/xmidt-org/vouch/oauth"
+)
+
+func TestProxy(t *testing.T) {
+	tests := []struct {
+		description  string
+		tokenStatus  int
+		stripPrefix  bool
+		expectStatus int
+		expectPath   string
+		expectReady  int
+	}{
+		{
+			description:  "Credentials are injected",
+			tokenStatus:  http.StatusOK,
+			expectStatus: http.StatusOK,
+			expectPath:   "/base/api/things",
+			expectReady:  http.StatusOK,
+		},
+		{
+			description:  "Prefix is stripped",
+			tokenStatus:  http.StatusOK,
+			stripPrefix:  true,
+			expectStatus: http.StatusOK,
+			expectPath:   "/base/things",
+			expectReady:  http.StatusOK,
+		},
+		{
+			description:  "Token endpoint is failing",
+			tokenStatus:  http.StatusInternalServerError,
+			expectStatus: http.StatusBadGateway,
+			expectReady:  http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.tokenStatus != http.StatusOK {
+					w.WriteHeader(tc.tokenStatus)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token": "upstream-token", "expires_in": 3600}`))
+			}))
+			defer tokens.Close()
+
+			var gotAuth, gotPath string
+			var calls int
+			upstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				gotAuth = r.Header.Get("Authorization")
+				gotPath = r.URL.Path
+				w.Write([]byte("upstream"))
+			}))
+			defer upstreamServer.Close()
+
+			p, err := newProxy(Config{
+				Routes: []Route{
+					{
+						Prefix:      "/api/",
+						StripPrefix: tc.stripPrefix,
+						Upstream:    upstreamServer.URL + "/base",
+						Vouch: vouch.Config{
+							OAuth: oauth.Config{
+								ClientID:     "client",
+								ClientSecret: "secret",
+								TokenURL:     tokens.URL,
+							},
+							InsecureHosts: []string{"127.0.0.1"},
+						},
+					},
+				},
+			}, slog.New(slog.DiscardHandler))
+			require.NoError(err)
+
+			front := httptest.NewServer(p)
+			defer front.Close()
+
+			req, err := http.NewRequest("GET", front.URL+"/api/things", nil)
+			require.NoError(err)
+			req.Header.Set("Authorization", "Bearer inbound")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(err)
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			assert.Equal(tc.expectStatus, resp.StatusCode)
+			if tc.expectStatus == http.StatusOK {
+				assert.Equal("upstream", string(body))
+				assert.Equal("Bearer upstream-token", gotAuth)
+				assert.Equal(tc.expectPath, gotPath)
+			} else {
+				assert.Zero(calls)
+			}
+
+			resp, err = http.Get(front.URL + readyPath)
+			require.NoError(err)
+			body, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(tc.expectReady, resp.StatusCode)
+			if tc.expectReady == http.StatusOK {
+				assert.Equal("ok\n", string(body))
+			} else {
+				// The reason is logged, never returned to the caller.
+				assert.Equal(http.StatusText(tc.expectReady)+"\n", string(body))
+			}
+		})
+	}
+}
+
+func TestNewProxy(t *testing.T) {
+	tests := []struct {
+		description string
+		config      Config
+	}{
+		{
+			description: "No routes",
+		},
+		{
+			description: "Invalid upstream",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/",
+						Upstream: "://invalid",
+					},
+				},
+			},
+		},
+		{
+			description: "Empty upstream",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/",
+						Upstream: "",
+					},
+				},
+			},
+		},
+		{
+			description: "Upstream without a host",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/",
+						Upstream: "/upstream",
+					},
+				},
+			},
+		},
+		{
+			description: "Empty prefix",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "",
+						Upstream: "https://example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Readiness prefix",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/readyz",
+						Upstream: "https://example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Duplicate prefix",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/api/",
+						Upstream: "https://a.example.com",
+					},
+					{
+						Prefix:   "/api/",
+						Upstream: "https://b.example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Invalid prefix",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "GET  /api/{",
+						Upstream: "https://example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Stripping a prefix with a method",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:      "GET /api/",
+						StripPrefix: true,
+						Upstream:    "https://example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Stripping a prefix with a host",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:      "api.example.com/api/",
+						StripPrefix: true,
+						Upstream:    "https://example.com",
+					},
+				},
+			},
+		},
+		{
+			description: "Invalid credentials",
+			config: Config{
+				Routes: []Route{
+					{
+						Prefix:   "/",
+						Upstream: "https://example.com",
+						Vouch: vouch.Config{
+							OAuth: oauth.Config{
+								ClientID: "client",
+								TokenURL: "http://example.com/token",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := newProxy(tc.config, slog.New(slog.DiscardHandler))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(os.WriteFile(file, []byte(`{
+		"Routes": [
+			{
+				"Prefix": "/api/",
+				"Upstream": "https://api.example.com",
+				"Vouch": {
+					"Basic": {"Username": "user", "Password": "pass"}
+				}
+			}
+		]
+	}`), 0600))
+
+	cfg, err := loadConfig(file)
+	require.NoError(err)
+	assert.Equal("127.0.0.1:8080", cfg.Listen)
+	require.Len(cfg.Routes, 1)
+	assert.Equal("user", cfg.Routes[0].Vouch.Basic.Username)
+
+	require.NoError(os.WriteFile(file, []byte(`{`), 0600))
+	_, err = loadConfig(file)
+	assert.Error(err)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package digest
+
+import (
+	"strings"
+)
+
+// authChallenge is a challenge of a WWW-Authenticate header.
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseChallenges parses the challenges of WWW-Authenticate header values as
+// described in RFC 9110 section 11.6.1. Parameter names are lower cased.
+// Malformed input ends the parsing of a value.
+func parseChallenges(values []string) []authChallenge {
+	var all []authChallenge
+	for _, v := range values {
+		p := parser{s: v}
+		var cur *authChallenge
+		for {
+			p.skip(", \t")
+			if p.done() {
+				break
+			}
+
+			token := p.token()
+			if token == "" {
+				break
+			}
+
+			p.skip(" \t")
+			if p.peek() == '=' {
+				// An auth-param of the current challenge.
+				p.next()
+				p.skip(" \t")
+				var value string
+				if p.peek() == '"' {
+					value = p.quoted()
+				} else {
+					value = p.token()
+				}
+				if cur != nil {
+					cur.params[strings.ToLower(token)] = value
+				}
+				continue
+			}
+
+			all = append(all, authChallenge{
+				scheme: token,
+				params: map[string]string{},
+			})
+			cur = &all[len(all)-1]
+		}
+	}
+	return all
+}
+
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *parser) next() {
+	p.i++
+}
+
+func (p *parser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// token reads a token.
+func (p *parser) token() string {
+	start := p.i
+	for !p.done() && strings.IndexByte(" \t,=\"", p.s[p.i]) < 0 {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// quoted reads a quoted string and returns its unescaped value.
+func (p *parser) quoted() string {
+	var b strings.Builder
+	p.next()
+	for !p.done() {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '\\':
+			if !p.done() {
+				b.WriteByte(p.s[p.i])
+				p.i++
+			}
+		case '"':
+			return b.String()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,428 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package digest implements HTTP Digest access authentication as described in
+// RFC 7616.
+package digest
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+const (
+	DIGEST_TYPE = "digest"
+)
+
+var errBodyNotReset = errors.New("digest cannot hash a request body without GetBody for qop auth-int")
+
+// algorithms are the supported algorithms, strongest first.
+var algorithms = []struct {
+	name string
+	hash func() hash.Hash
+}{
+	{name: "SHA-512-256", hash: sha512.New512_256},
+	{name: "SHA-256", hash: sha256.New},
+	{name: "MD5", hash: md5.New},
+}
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 200.
+	Priority int
+
+	// Username is the username to authenticate with.
+	Username string
+
+	// Password is the password to authenticate with.
+	Password string
+}
+
+func (c *Config) IsActive() bool {
+	return c.Username != "" || c.Password != ""
+}
+
+// Digest answers the Digest challenges of servers. Requests to a server are
+// sent without credentials until it issues a challenge; later requests in the
+// protection space of the challenge are decorated with it.
+type Digest struct {
+	priority int
+	username string
+	password string
+	cnonce   func() string
+	dispatch func(any)
+
+	mu         sync.Mutex
+	seq        uint64
+	challenges map[space]*challenge
+}
+
+// space identifies a protection space: a realm of a server.
+type space struct {
+	origin string
+	realm  string
+}
+
+// prefix is a URI of the domain of a protection space.
+type prefix struct {
+	origin string
+	path   string
+}
+
+// challenge is the state of the Digest challenge of a protection space.
+type challenge struct {
+	origin    string
+	domain    []prefix
+	seq       uint64
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	hash      func() hash.Hash
+	sess      bool
+	qop       string
+	userhash  bool
+	nc        int
+}
+
+func (d *Digest) Priority() int {
+	return d.priority
+}
+
+// New creates a decorator that answers Digest challenges. If the
+// configuration is not active, New returns nil.
+func New(config Config, dispatch func(any)) *Digest {
+	if !config.IsActive() {
+		return nil
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 200
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	return &Digest{
+		priority:   priority,
+		username:   config.Username,
+		password:   config.Password,
+		cnonce:     newCnonce,
+		dispatch:   dispatch,
+		challenges: map[space]*challenge{},
+	}
+}
+
+// Decorate sets the Authorization header on a request to a server that has
+// issued a Digest challenge. Requests to other servers are left alone so the
+// server can issue one.
+func (d *Digest) Decorate(req *http.Request) error {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: DIGEST_TYPE,
+	}
+
+	err := d.decorate(req)
+	evnt.Duration = time.Since(evnt.At)
+	evnt.Err = err
+	d.dispatch(evnt)
+	return err
+}
+
+func (d *Digest) decorate(req *http.Request) error {
+	d.mu.Lock()
+	c := d.lookup(req.URL)
+	var nc int
+	var ch challenge
+	if c != nil {
+		c.nc++
+		nc, ch = c.nc, *c
+	}
+	d.mu.Unlock()
+
+	if c == nil {
+		return nil
+	}
+
+	uri := req.URL.RequestURI()
+	cnonce := d.cnonce()
+
+	ncValue := fmt.Sprintf("%08x", nc)
+	response, err := d.response(req, &ch, uri, ncValue, cnonce)
+	if err != nil {
+		return err
+	}
+
+	username := d.username
+	if ch.userhash {
+		username = ch.digest(d.username + ":" + ch.realm)
+	}
+
+	params := []string{
+		"username=" + quote(username),
+		"realm=" + quote(ch.realm),
+		"uri=" + quote(uri),
+		"algorithm=" + ch.algorithm,
+		"nonce=" + quote(ch.nonce),
+	}
+	if ch.qop != "" {
+		params = append(params,
+			"nc="+ncValue,
+			"cnonce="+quote(cnonce),
+			"qop="+ch.qop,
+		)
+	}
+	params = append(params, "response="+quote(response))
+	if ch.opaque != "" {
+		params = append(params, "opaque="+quote(ch.opaque))
+	}
+	if ch.userhash {
+		params = append(params, "userhash=true")
+	}
+
+	req.Header.Set("Authorization", "Digest "+strings.Join(params, ", "))
+	return nil
+}
+
+// response computes the request digest of RFC 7616 from HA1, the hash of
+// the credentials, and HA2, the hash of the method and URI.
+func (d *Digest) response(req *http.Request, ch *challenge, uri, ncValue, cnonce string) (string, error) {
+	ha1 := ch.digest(d.username + ":" + ch.realm + ":" + d.password)
+	if ch.sess {
+		ha1 = ch.digest(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+
+	a2 := req.Method + ":" + uri
+	if ch.qop == "auth-int" {
+		body, err := bodyHash(req, ch.hash)
+		if err != nil {
+			return "", err
+		}
+		a2 += ":" + body
+	}
+	ha2 := ch.digest(a2)
+
+	if ch.qop == "" {
+		return ch.digest(ha1 + ":" + ch.nonce + ":" + ha2), nil
+	}
+	return ch.digest(ha1 + ":" + ch.nonce + ":" + ncValue + ":" + cnonce + ":" + ch.qop + ":" + ha2), nil
+}
+
+// digest returns the hex encoded hash of s with the algorithm of the
+// challenge.
+func (c *challenge) digest(s string) string {
+	sum := c.hash()
+	io.WriteString(sum, s)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// Challenge caches the strongest supported Digest challenge of a 401
+// response. It returns true if the request should be retried: when it was
+// sent without a Digest response or the server reports the nonce as stale.
+// A request rejected for any other reason is not retried, since the
+// credentials are wrong.
+func (d *Digest) Challenge(req *http.Request, resp *http.Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	c, stale := pick(req.URL, parseChallenges(resp.Header.Values("WWW-Authenticate")))
+	if c == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	d.seq++
+	c.seq = d.seq
+	d.challenges[space{origin: c.origin, realm: c.realm}] = c
+	d.mu.Unlock()
+
+	scheme, _, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	return stale || !strings.EqualFold(scheme, "Digest")
+}
+
+// lookup returns the challenge whose protection space covers a URL. The
+// space with the longest matching domain wins; among spaces that cover the
+// whole server, the most recently challenged one wins. The caller must hold
+// d.mu.
+func (d *Digest) lookup(u *url.URL) *challenge {
+	o, path := origin(u), escapedPath(u)
+
+	var best *challenge
+	bestLen := -1
+	for _, c := range d.challenges {
+		n := c.match(o, path)
+		if n < 0 {
+			continue
+		}
+		if n > bestLen || (n == bestLen && c.seq > best.seq) {
+			best, bestLen = c, n
+		}
+	}
+	return best
+}
+
+// match returns the length of the domain URI of the challenge that covers
+// the origin and path, or -1 if none does. A challenge without a domain
+// covers its whole server.
+func (c *challenge) match(origin, path string) int {
+	if len(c.domain) == 0 {
+		if origin == c.origin {
+			return 0
+		}
+		return -1
+	}
+
+	n := -1
+	for _, p := range c.domain {
+		if p.origin == origin && hasPathPrefix(path, p.path) && len(p.path) > n {
+			n = len(p.path)
+		}
+	}
+	return n
+}
+
+// hasPathPrefix reports whether path is within the prefix, on a segment
+// boundary.
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// parseDomain resolves the space separated URIs of the domain parameter of
+// a challenge against the URL of the challenged request. URIs that do not
+// parse are ignored.
+func parseDomain(base *url.URL, domain string) []prefix {
+	var all []prefix
+	for _, field := range strings.Fields(domain) {
+		ref, err := url.Parse(field)
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		all = append(all, prefix{origin: origin(u), path: escapedPath(u)})
+	}
+	return all
+}
+
+// pick returns the Digest challenge with the strongest supported algorithm
+// and whether its nonce is stale. u is the URL of the challenged request.
+func pick(u *url.URL, all []authChallenge) (*challenge, bool) {
+	for _, alg := range algorithms {
+		for _, ac := range all {
+			if !strings.EqualFold(ac.scheme, "Digest") {
+				continue
+			}
+
+			name := ac.params["algorithm"]
+			if name == "" {
+				name = "MD5"
+			}
+			base, sess := strings.CutSuffix(strings.ToUpper(name), "-SESS")
+			if base != alg.name || ac.params["nonce"] == "" {
+				continue
+			}
+
+			qop, ok := pickQOP(ac.params["qop"])
+			if !ok {
+				continue
+			}
+
+			return &challenge{
+				origin:    origin(u),
+				domain:    parseDomain(u, ac.params["domain"]),
+				realm:     ac.params["realm"],
+				nonce:     ac.params["nonce"],
+				opaque:    ac.params["opaque"],
+				algorithm: name,
+				hash:      alg.hash,
+				sess:      sess,
+				qop:       qop,
+				userhash:  strings.EqualFold(ac.params["userhash"], "true"),
+			}, strings.EqualFold(ac.params["stale"], "true")
+		}
+	}
+	return nil, false
+}
+
+// pickQOP returns the quality of protection to use from those offered,
+// preferring "auth". A challenge without qop uses the original RFC 2069
+// computation.
+func pickQOP(offered string) (string, bool) {
+	if offered == "" {
+		return "", true
+	}
+
+	var authInt bool
+	for _, q := range strings.Split(offered, ",") {
+		switch strings.ToLower(strings.TrimSpace(q)) {
+		case "auth":
+			return "auth", true
+		case "auth-int":
+			authInt = true
+		}
+	}
+	return "auth-int", authInt
+}
+
+// origin identifies the server of a URL.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// escapedPath returns the path of a URL, "/" if it is empty.
+func escapedPath(u *url.URL) string {
+	if p := u.EscapedPath(); p != "" {
+		return p
+	}
+	return "/"
+}
+
+// bodyHash returns the hash of the request body for qop auth-int.
+func bodyHash(req *http.Request, newHash func() hash.Hash) (string, error) {
+	h := newHash()
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return "", errBodyNotReset
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func newCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawStdEncoding.EncodeToString(b)
+}
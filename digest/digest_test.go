@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+)
+
+// The example of RFC 7616 section 3.9.1.
+const (
+	rfcNonce  = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+	rfcOpaque = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+	rfcCnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func rfcChallenge(alg string) string {
+	return `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=` + alg +
+		`, nonce="` + rfcNonce + `", opaque="` + rfcOpaque + `"`
+}
+
+func unauthorized(challenges ...string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": challenges},
+	}
+}
+
+func TestDecorateRFC7616(t *testing.T) {
+	tests := []struct {
+		description string
+		challenges  []string
+		expectAuth  string
+	}{
+		{
+			description: "SHA-256 is preferred over MD5",
+			challenges:  []string{rfcChallenge("MD5"), rfcChallenge("SHA-256")},
+			expectAuth: `Digest username="Mufasa", realm="http-auth@example.org", uri="/dir/index.html", algorithm=SHA-256, ` +
+				`nonce="` + rfcNonce + `", nc=00000001, cnonce="` + rfcCnonce + `", qop=auth, ` +
+				`response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", opaque="` + rfcOpaque + `"`,
+		},
+		{
+			description: "MD5",
+			challenges:  []string{rfcChallenge("MD5")},
+			expectAuth: `Digest username="Mufasa", realm="http-auth@example.org", uri="/dir/index.html", algorithm=MD5, ` +
+				`nonce="` + rfcNonce + `", nc=00000001, cnonce="` + rfcCnonce + `", qop=auth, ` +
+				`response="8ca523f5e9506fed4657c9700eebdbec", opaque="` + rfcOpaque + `"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var got []events.DecorateEvent
+			d := New(Config{Username: "Mufasa", Password: "Circle of Life"}, func(e any) {
+				got = append(got, e.(events.DecorateEvent))
+			})
+			d.cnonce = func() string { return rfcCnonce }
+
+			req, err := http.NewRequest("GET", "https://www.example.org/dir/index.html", nil)
+			require.NoError(t, err)
+
+			// Nothing is known about the server before its challenge.
+			require.NoError(t, d.Decorate(req))
+			assert.Empty(t, req.Header.Get("Authorization"))
+
+			require.True(t, d.Challenge(req, unauthorized(tc.challenges...)))
+			require.NoError(t, d.Decorate(req))
+			assert.Equal(t, tc.expectAuth, req.Header.Get("Authorization"))
+
+			// Later requests to the server are decorated preemptively with
+			// the next nonce count.
+			req, err = http.NewRequest("GET", "https://www.example.org/dir/index.html", nil)
+			require.NoError(t, err)
+			require.NoError(t, d.Decorate(req))
+			assert.Contains(t, req.Header.Get("Authorization"), "nc=00000002")
+
+			// Other servers are not.
+			req, err = http.NewRequest("GET", "https://other.example.org/dir/index.html", nil)
+			require.NoError(t, err)
+			require.NoError(t, d.Decorate(req))
+			assert.Empty(t, req.Header.Get("Authorization"))
+
+			require.Len(t, got, 4)
+			assert.Equal(t, DIGEST_TYPE, got[0].Type)
+		})
+	}
+}
+
+func TestDecorateVariants(t *testing.T) {
+	h := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		description string
+		challenge   string
+		body        string
+		expect      func(ha1 string) []string
+	}{
+		{
+			description: "Session algorithm with a hashed username",
+			challenge:   `Digest realm="r", nonce="n", algorithm=SHA-256-sess, qop="auth", userhash=true`,
+			expect: func(ha1 string) []string {
+				ha1 = h(ha1 + ":n:c")
+				return []string{
+					`username="` + h("user:r") + `"`,
+					"algorithm=SHA-256-sess",
+					`response="` + h(ha1+":n:00000001:c:auth:"+h("GET:/p?q=1")) + `"`,
+					"userhash=true",
+				}
+			},
+		},
+		{
+			description: "Integrity protection",
+			challenge:   `Digest realm="r", nonce="n", algorithm=SHA-256, qop="auth-int"`,
+			body:        "data",
+			expect: func(ha1 string) []string {
+				return []string{
+					"qop=auth-int",
+					`response="` + h(ha1+":n:00000001:c:auth-int:"+h("GET:/p?q=1:"+h("data"))) + `"`,
+				}
+			},
+		},
+		{
+			description: "Without quality of protection",
+			challenge:   `Digest realm="r", nonce="n", algorithm=SHA-256`,
+			expect: func(ha1 string) []string {
+				return []string{`response="` + h(ha1+":n:"+h("GET:/p?q=1")) + `"`}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			d := New(Config{Username: "user", Password: "pass"}, nil)
+			d.cnonce = func() string { return "c" }
+
+			req, err := http.NewRequest("GET", "https://example.com/p?q=1", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			require.True(t, d.Challenge(req, unauthorized(tc.challenge)))
+			require.NoError(t, d.Decorate(req))
+
+			auth := req.Header.Get("Authorization")
+			for _, want := range tc.expect(h("user:r:pass")) {
+				assert.Contains(t, auth, want)
+			}
+			if !strings.Contains(tc.challenge, "qop") {
+				assert.NotContains(t, auth, "nc=")
+			}
+		})
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	tests := []struct {
+		description string
+		auth        string
+		resp        *http.Response
+		expect      bool
+		expectAuth  bool
+	}{
+		{
+			description: "First challenge",
+			resp:        unauthorized(rfcChallenge("SHA-256")),
+			expect:      true,
+			expectAuth:  true,
+		},
+		{
+			description: "Rejected credentials",
+			auth:        "Digest username=\"Mufasa\"",
+			resp:        unauthorized(rfcChallenge("SHA-256")),
+			expectAuth:  true,
+		},
+		{
+			description: "Stale nonce",
+			auth:        "Digest username=\"Mufasa\"",
+			resp:        unauthorized(rfcChallenge("SHA-256") + ", stale=true"),
+			expect:      true,
+			expectAuth:  true,
+		},
+		{
+			description: "Other schemes only",
+			resp:        unauthorized(`Basic realm="r", Bearer`),
+		},
+		{
+			description: "Unsupported algorithm",
+			resp:        unauthorized(`Digest realm="r", nonce="n", algorithm=SHA-1`),
+		},
+		{
+			description: "Unsupported quality of protection",
+			resp:        unauthorized(`Digest realm="r", nonce="n", qop="other"`),
+		},
+		{
+			description: "Not a 401",
+			resp:        &http.Response{StatusCode: http.StatusForbidden},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			d := New(Config{Username: "Mufasa", Password: "Circle of Life"}, nil)
+
+			req, err := http.NewRequest("GET", "https://www.example.org/", nil)
+			require.NoError(t, err)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+
+			assert.Equal(t, tc.expect, d.Challenge(req, tc.resp))
+
+			req, err = http.NewRequest("GET", "https://www.example.org/", nil)
+			require.NoError(t, err)
+			require.NoError(t, d.Decorate(req))
+			assert.Equal(t, tc.expectAuth, req.Header.Get("Authorization") != "")
+		})
+	}
+}
+
+func TestProtectionSpaces(t *testing.T) {
+	tests := []struct {
+		description string
+		challenges  map[string]string
+		expect      map[string]string
+	}{
+		{
+			description: "Realms with domains",
+			challenges: map[string]string{
+				"/a/x": `Digest realm="a", nonce="na", qop="auth", domain="/a/ https://other.example.org/shared"`,
+				"/b/y": `Digest realm="b", nonce="nb", qop="auth", domain="/b"`,
+			},
+			expect: map[string]string{
+				"https://www.example.org/a/z":        `realm="a"`,
+				"https://www.example.org/b":          `realm="b"`,
+				"https://www.example.org/b/z":        `realm="b"`,
+				"https://www.example.org/bz":         "",
+				"https://www.example.org/c":          "",
+				"https://other.example.org/shared/z": `realm="a"`,
+			},
+		},
+		{
+			description: "A domain is preferred over the whole server",
+			challenges: map[string]string{
+				"/a/x": `Digest realm="a", nonce="na", qop="auth", domain="/a/"`,
+				"/b/y": `Digest realm="b", nonce="nb", qop="auth"`,
+			},
+			expect: map[string]string{
+				"https://www.example.org/a/z": `realm="a"`,
+				"https://www.example.org/c":   `realm="b"`,
+				"https://other.example.org/c": "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			d := New(Config{Username: "Mufasa", Password: "Circle of Life"}, nil)
+
+			for path, challenge := range tc.challenges {
+				req, err := http.NewRequest("GET", "https://www.example.org"+path, nil)
+				require.NoError(t, err)
+				require.True(t, d.Challenge(req, unauthorized(challenge)))
+			}
+
+			for u, realm := range tc.expect {
+				req, err := http.NewRequest("GET", u, nil)
+				require.NoError(t, err)
+				require.NoError(t, d.Decorate(req))
+
+				auth := req.Header.Get("Authorization")
+				if realm == "" {
+					assert.Empty(t, auth, u)
+				} else {
+					assert.Contains(t, auth, realm, u)
+				}
+			}
+		})
+	}
+}
+
+func TestProtectionSpaceNonceCounts(t *testing.T) {
+	d := New(Config{Username: "Mufasa", Password: "Circle of Life"}, nil)
+
+	// Each realm of a server keeps its own nonce and count.
+	for _, realm := range []string{"a", "b"} {
+		req, err := http.NewRequest("GET", "https://www.example.org/"+realm, nil)
+		require.NoError(t, err)
+		require.True(t, d.Challenge(req, unauthorized(`Digest realm="`+realm+`", nonce="n`+realm+`", qop="auth", domain="/`+realm+`"`)))
+	}
+
+	for _, step := range []struct {
+		path   string
+		expect string
+	}{
+		{path: "/a", expect: `realm="a", uri="/a", algorithm=MD5, nonce="na", nc=00000001`},
+		{path: "/b", expect: `realm="b", uri="/b", algorithm=MD5, nonce="nb", nc=00000001`},
+		{path: "/a", expect: `realm="a", uri="/a", algorithm=MD5, nonce="na", nc=00000002`},
+	} {
+		req, err := http.NewRequest("GET", "https://www.example.org"+step.path, nil)
+		require.NoError(t, err)
+		require.NoError(t, d.Decorate(req))
+		assert.Contains(t, req.Header.Get("Authorization"), step.expect)
+	}
+}
+
+func TestParseChallenges(t *testing.T) {
+	got := parseChallenges([]string{
+		`Basic realm="simple", Digest realm="a \"quoted\", realm", nonce=abc, qop="auth"`,
+		`Bearer`,
+	})
+
+	assert.Equal(t, []authChallenge{
+		{scheme: "Basic", params: map[string]string{"realm": "simple"}},
+		{scheme: "Digest", params: map[string]string{"realm": `a "quoted", realm`, "nonce": "abc", "qop": "auth"}},
+		{scheme: "Bearer", params: map[string]string{}},
+	}, got)
+}
+
+func TestNew(t *testing.T) {
+	assert.Nil(t, New(Config{}, nil))
+
+	d := New(Config{Username: "user", Password: "pass"}, nil)
+	require.NotNil(t, d)
+	assert.Equal(t, 200, d.Priority())
+}
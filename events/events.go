@@ -26,6 +26,14 @@ type FetchEvent struct {
 	// any adjustments made by the client.
 	OriginalExpiration time.Time
 
+	// Forced is true when the fetch was made because a cached token was
+	// rejected by the server before it expired.
+	Forced bool
+
+	// Rotated is true when the server answered the redemption of a refresh
+	// token with a new refresh token.
+	Rotated bool
+
 	// Error is the error returned from the OAuth service.
 	Err error
 }
@@ -78,3 +86,37 @@ type DecorateEventListenerFunc func(DecorateEvent)
 func (f DecorateEventListenerFunc) OnDecorateEvent(e DecorateEvent) {
 	f(e)
 }
+
+// RedirectEvent is the event that is sent about a redirect decision.
+type RedirectEvent struct {
+	// At holds the time when the redirect was evaluated.
+	At time.Time
+
+	// From is the URL of the request that was redirected.
+	From string
+
+	// To is the URL the request is being redirected to.
+	To string
+
+	// Allowed is true when credentials were applied to the redirected
+	// request and false when they were removed from it.
+	Allowed bool
+
+	// Error is the error returned when decorating the redirected request.
+	Err error
+}
+
+// RedirectEventListener is the interface that must be implemented by types that
+// want to receive RedirectEvent notifications.
+type RedirectEventListener interface {
+	OnRedirectEvent(RedirectEvent)
+}
+
+// RedirectEventListenerFunc is a function type that implements RedirectEventListener.
+// It can be used as an adapter for functions that need to implement the
+// RedirectEventListener interface.
+type RedirectEventListenerFunc func(RedirectEvent)
+
+func (f RedirectEventListenerFunc) OnRedirectEvent(e RedirectEvent) {
+	f(e)
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package grpcauth adapts a vouch.Vouch to gRPC per-RPC credentials.
+//
+// Credentials implements the method set of the
+// google.golang.org/grpc/credentials.PerRPCCredentials interface without
+// importing gRPC, so it can be used with grpc.WithPerRPCCredentials:
+//
+//	conn, err := grpc.NewClient(target,
+//		grpc.WithTransportCredentials(credentials.NewTLS(nil)),
+//		grpc.WithPerRPCCredentials(&grpcauth.Credentials{Vouch: v}),
+//	)
+//
+// Per-RPC credentials carry a single value per key. Metadata returns every
+// value of the headers, in the form of a metadata.MD, for interceptors that
+// send it with metadata.NewOutgoingContext.
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/vouch"
+)
+
+var (
+	errNilVouch       = errors.New("grpcauth: Credentials' Vouch is nil")
+	errMultipleValues = errors.New("grpcauth: header has more than one value")
+)
+
+// Credentials provides the headers of a vouch.Vouch as gRPC request metadata.
+type Credentials struct {
+	// Vouch provides the credentials.
+	Vouch *vouch.Vouch
+
+	// AllowInsecure allows the credentials to be sent over connections
+	// without transport security. It should only be used for tests.
+	AllowInsecure bool
+}
+
+// GetRequestMetadata returns the credentials as request metadata. The context
+// bounds how long it waits for credentials, such as an OAuth token, to be
+// fetched. Headers with more than one value cannot be sent as per-RPC
+// credentials and are an error; use Metadata for them.
+func (c *Credentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	md, err := c.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return single(md)
+}
+
+// Metadata returns the credentials as request metadata with every value of
+// each header, keyed by the lower case header name like metadata.MD. The
+// context bounds how long it waits for credentials to be fetched.
+func (c *Credentials) Metadata(ctx context.Context) (map[string][]string, error) {
+	if c.Vouch == nil {
+		return nil, errNilVouch
+	}
+
+	h, err := c.Vouch.Headers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return metadata(h), nil
+}
+
+// single returns the only value of each key of the metadata.
+func single(md map[string][]string) (map[string]string, error) {
+	m := make(map[string]string, len(md))
+	for k, v := range md {
+		if len(v) != 1 {
+			return nil, fmt.Errorf("%w: %s", errMultipleValues, k)
+		}
+		m[k] = v[0]
+	}
+	return m, nil
+}
+
+// metadata appends every value of the headers to the metadata, the way
+// metadata.MD.Append does.
+func metadata(h http.Header) map[string][]string {
+	md := make(map[string][]string, len(h))
+	for k, v := range h {
+		k = strings.ToLower(k)
+		md[k] = append(md[k], v...)
+	}
+	return md
+}
+
+// RequireTransportSecurity reports whether the credentials require a
+// connection with transport security.
+func (c *Credentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch"
+	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/oauth"
+)
+
+// perRPCCredentials is the method set of the gRPC PerRPCCredentials interface.
+type perRPCCredentials interface {
+	GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error)
+	RequireTransportSecurity() bool
+}
+
+var _ perRPCCredentials = (*Credentials)(nil)
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		description    string
+		config         vouch.Config
+		allowInsecure  bool
+		expectMetadata map[string]string
+		expectError    bool
+	}{
+		{
+			description: "Basic credentials",
+			config: vouch.Config{
+				Basic: basic.Config{
+					Username: "user",
+					Password: "pass",
+				},
+			},
+			expectMetadata: map[string]string{
+				"authorization": "Basic dXNlcjpwYXNz",
+			},
+		},
+		{
+			description:    "No credentials",
+			allowInsecure:  true,
+			expectMetadata: map[string]string{},
+		},
+		{
+			description: "Only routes",
+			config: vouch.Config{
+				Routes: []vouch.Route{
+					{
+						Host: "example.com",
+					},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var decorated []events.DecorateEvent
+			v, err := vouch.New(tc.config,
+				vouch.WithDecorateEventListener(events.DecorateEventListenerFunc(func(e events.DecorateEvent) {
+					decorated = append(decorated, e)
+				})),
+			)
+			require.NoError(err)
+
+			c := Credentials{
+				Vouch:         v,
+				AllowInsecure: tc.allowInsecure,
+			}
+			assert.Equal(!tc.allowInsecure, c.RequireTransportSecurity())
+
+			md, err := c.GetRequestMetadata(context.Background(), "https://example.com/pkg.Service")
+			if tc.expectError {
+				assert.Error(err)
+				require.Len(decorated, 1)
+				assert.Error(decorated[0].Err)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(tc.expectMetadata, md)
+			if len(tc.expectMetadata) > 0 {
+				require.Len(decorated, 1)
+				assert.Equal(basic.BASIC_TYPE, decorated[0].Type)
+			}
+
+			all, err := c.Metadata(context.Background())
+			require.NoError(err)
+			assert.Len(all, len(tc.expectMetadata))
+			for k, v := range tc.expectMetadata {
+				assert.Equal([]string{v}, all[k])
+			}
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	tests := []struct {
+		description  string
+		header       http.Header
+		expectAll    map[string][]string
+		expectSingle map[string]string
+		expectError  error
+	}{
+		{
+			description:  "Single values",
+			header:       http.Header{"Authorization": {"Bearer token"}, "X-Api-Key": {"key"}},
+			expectAll:    map[string][]string{"authorization": {"Bearer token"}, "x-api-key": {"key"}},
+			expectSingle: map[string]string{"authorization": "Bearer token", "x-api-key": "key"},
+		},
+		{
+			description: "Every value is kept",
+			header:      http.Header{"Signature": {"a=:one:", "b=:two:"}},
+			expectAll:   map[string][]string{"signature": {"a=:one:", "b=:two:"}},
+			expectError: errMultipleValues,
+		},
+		{
+			description: "Keys differing in case are appended",
+			header:      http.Header{"X-Key": {"one"}, "x-key": {"two"}},
+			expectAll:   map[string][]string{"x-key": {"one", "two"}},
+			expectError: errMultipleValues,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			md := metadata(tc.header)
+			for k, v := range tc.expectAll {
+				assert.ElementsMatch(v, md[k])
+			}
+			assert.Len(md, len(tc.expectAll))
+
+			m, err := single(md)
+			assert.ErrorIs(err, tc.expectError)
+			assert.Equal(tc.expectSingle, m)
+		})
+	}
+}
+
+func TestCredentialsDeadline(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var decorated []events.DecorateEvent
+	v, err := vouch.New(vouch.Config{
+		OAuth: oauth.Config{
+			ClientID: "client",
+			TokenURL: server.URL,
+		},
+		InsecureHosts: []string{"127.0.0.1"},
+	}, vouch.WithDecorateEventListener(events.DecorateEventListenerFunc(func(e events.DecorateEvent) {
+		decorated = append(decorated, e)
+	})))
+	require.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := Credentials{Vouch: v}
+	_, err = c.GetRequestMetadata(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.NotEmpty(decorated)
+}
+
+func TestCredentialsNilVouch(t *testing.T) {
+	var c Credentials
+	_, err := c.GetRequestMetadata(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,466 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package httpsig signs requests with HTTP Message Signatures as described in
+// RFC 9421.
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+const (
+	HTTPSIG_TYPE = "httpsig"
+)
+
+// The signature algorithms of the HTTP Signature Algorithms registry.
+const (
+	AlgHMACSHA256      = "hmac-sha256"
+	AlgRSAPSSSHA512    = "rsa-pss-sha512"
+	AlgRSAV15SHA256    = "rsa-v1_5-sha256"
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgECDSAP384SHA384 = "ecdsa-p384-sha384"
+	AlgEd25519         = "ed25519"
+)
+
+const (
+	componentDigest     = "content-digest"
+	defaultLabel        = "sig1"
+	defaultDigest       = "sha-256"
+	headerSignature     = "Signature"
+	headerSignatureIn   = "Signature-Input"
+	headerContentDigest = "Content-Digest"
+)
+
+var (
+	errNoKeyID         = errors.New("httpsig KeyID is required")
+	errKeyAndSecret    = errors.New("httpsig KeyFile and Secret are mutually exclusive")
+	errBodyNotReset    = errors.New("httpsig cannot digest a request body without GetBody")
+	errMissingHeader   = errors.New("httpsig component is not present in the request")
+	errUnknownDerived  = errors.New("httpsig derived component is not supported")
+	errAlgorithmForKey = errors.New("httpsig Algorithm does not match the key")
+)
+
+// defaultComponents are signed when Components is empty.
+var defaultComponents = []string{"@method", "@target-uri", componentDigest}
+
+// digests are the supported Content-Digest algorithms.
+var digests = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 700.
+	Priority int
+
+	// KeyID is the keyid parameter identifying the key to the server.
+	KeyID string
+
+	// KeyFile is the path of the PEM encoded RSA, ECDSA or Ed25519 private
+	// key to sign with.
+	KeyFile string
+
+	// Secret is the shared secret to sign with using HMAC, used instead of
+	// KeyFile.
+	Secret string
+
+	// Algorithm is the signature algorithm. "" means the default for the
+	// key: "rsa-pss-sha512" for RSA keys, "ecdsa-p256-sha256" or
+	// "ecdsa-p384-sha384" for ECDSA keys, "ed25519" for Ed25519 keys and
+	// "hmac-sha256" for a Secret. "rsa-v1_5-sha256" may be used with RSA keys.
+	Algorithm string
+
+	// Label is the label of the signature in the Signature and
+	// Signature-Input headers. "" means default, which is "sig1".
+	Label string
+
+	// Components are the component identifiers to sign, such as "@method",
+	// "@authority", "@path", "@query" or a lower case header name. The
+	// default is "@method", "@target-uri" and "content-digest".
+	// "content-digest" is only signed when the request has a body.
+	Components []string
+
+	// Digest is the algorithm of the Content-Digest computed for requests
+	// with a body. Valid values: ""/"sha-256", "sha-512"
+	Digest string
+
+	// Expires is how long the signature is valid for. If positive, the
+	// expires parameter is set.
+	Expires time.Duration
+
+	// Nonce adds a random nonce parameter to each signature.
+	Nonce bool
+
+	// Tag is the optional tag parameter of the signature.
+	Tag string
+}
+
+func (c *Config) IsActive() bool {
+	return c.KeyFile != "" || c.Secret != ""
+}
+
+type Signer struct {
+	priority   int
+	keyID      string
+	label      string
+	components []string
+	digest     string
+	expires    time.Duration
+	nonce      bool
+	tag        string
+	sign       func([]byte) ([]byte, error)
+	now        func() time.Time
+	dispatch   func(any)
+}
+
+func (s *Signer) Priority() int {
+	return s.priority
+}
+
+// New creates a decorator that signs requests. If the configuration is not
+// active, New returns nil.
+func New(config Config, dispatch func(any)) (*Signer, error) {
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	if config.KeyID == "" {
+		return nil, errNoKeyID
+	}
+	if config.KeyFile != "" && config.Secret != "" {
+		return nil, errKeyAndSecret
+	}
+
+	digest := config.Digest
+	if digest == "" {
+		digest = defaultDigest
+	}
+	if _, ok := digests[digest]; !ok {
+		return nil, fmt.Errorf("invalid Digest: %s", config.Digest)
+	}
+
+	components, err := componentsFor(config.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := loadSigner(config)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 700
+	}
+	label := config.Label
+	if label == "" {
+		label = defaultLabel
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	return &Signer{
+		priority:   priority,
+		keyID:      config.KeyID,
+		label:      label,
+		components: components,
+		digest:     digest,
+		expires:    config.Expires,
+		nonce:      config.Nonce,
+		tag:        config.Tag,
+		sign:       sign,
+		now:        time.Now,
+		dispatch:   dispatch,
+	}, nil
+}
+
+// componentsFor returns the lower cased components to sign, or the default
+// components if there are none. Unknown derived components are rejected.
+func componentsFor(list []string) ([]string, error) {
+	if len(list) == 0 {
+		list = defaultComponents
+	}
+	components := slices.Clone(list)
+	for i, c := range components {
+		c = strings.ToLower(c)
+		if strings.HasPrefix(c, "@") && derive[c] == nil {
+			return nil, fmt.Errorf("%w: %s", errUnknownDerived, c)
+		}
+		components[i] = c
+	}
+	return components, nil
+}
+
+// loadSigner returns the signing function of the Secret, or of the key read
+// from the KeyFile.
+func loadSigner(config Config) (func([]byte) ([]byte, error), error) {
+	if config.Secret != "" {
+		if config.Algorithm != "" && config.Algorithm != AlgHMACSHA256 {
+			return nil, fmt.Errorf("%w: %s", errAlgorithmForKey, config.Algorithm)
+		}
+		secret := []byte(config.Secret)
+		return func(base []byte) ([]byte, error) {
+			h := hmac.New(sha256.New, secret)
+			h.Write(base)
+			return h.Sum(nil), nil
+		}, nil
+	}
+
+	data, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KeyFile %s: %w", config.KeyFile, err)
+	}
+	return signerFor(key, config.Algorithm)
+}
+
+// signerFor returns the signing function of the algorithm for the key.
+func signerFor(key crypto.Signer, alg string) (func([]byte) ([]byte, error), error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		switch alg {
+		case "", AlgRSAPSSSHA512:
+			return func(base []byte) ([]byte, error) {
+				digest := sha512.Sum512(base)
+				return rsa.SignPSS(rand.Reader, k, crypto.SHA512, digest[:], &rsa.PSSOptions{
+					SaltLength: 64,
+				})
+			}, nil
+		case AlgRSAV15SHA256:
+			return func(base []byte) ([]byte, error) {
+				digest := sha256.Sum256(base)
+				return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+			}, nil
+		}
+	case *ecdsa.PrivateKey:
+		switch {
+		case k.Curve == elliptic.P256() && (alg == "" || alg == AlgECDSAP256SHA256):
+			return func(base []byte) ([]byte, error) {
+				digest := sha256.Sum256(base)
+				return jwt.SignECDSA(k, digest[:])
+			}, nil
+		case k.Curve == elliptic.P384() && (alg == "" || alg == AlgECDSAP384SHA384):
+			return func(base []byte) ([]byte, error) {
+				digest := sha512.Sum384(base)
+				return jwt.SignECDSA(k, digest[:])
+			}, nil
+		}
+	case ed25519.PrivateKey:
+		if alg == "" || alg == AlgEd25519 {
+			return func(base []byte) ([]byte, error) {
+				return ed25519.Sign(k, base), nil
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", errAlgorithmForKey, alg)
+}
+
+// Strip removes the headers set by Decorate, for a request that is
+// redirected to another host, which could otherwise replay the signature
+// against the original target.
+func (s *Signer) Strip(req *http.Request) {
+	req.Header.Del(headerSignature)
+	req.Header.Del(headerSignatureIn)
+	req.Header.Del(headerContentDigest)
+}
+
+// Decorate sets the Content-Digest of a request with a body, and the
+// Signature and Signature-Input headers.
+func (s *Signer) Decorate(req *http.Request) error {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: HTTPSIG_TYPE,
+	}
+
+	err := s.decorate(req)
+	evnt.Duration = time.Since(evnt.At)
+	evnt.Err = err
+	s.dispatch(evnt)
+	return err
+}
+
+func (s *Signer) decorate(req *http.Request) error {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody {
+		digest, err := s.contentDigest(req)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(headerContentDigest, digest)
+	}
+
+	components := make([]string, 0, len(s.components))
+	for _, c := range s.components {
+		if c == componentDigest && !hasBody {
+			continue
+		}
+		components = append(components, c)
+	}
+
+	params := s.params(components)
+
+	var base strings.Builder
+	for _, c := range components {
+		value, err := componentValue(req, c)
+		if err != nil {
+			return err
+		}
+		base.WriteString(sfString(c) + ": " + value + "\n")
+	}
+	base.WriteString(`"@signature-params": ` + params)
+
+	sig, err := s.sign([]byte(base.String()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(headerSignatureIn, s.label+"="+params)
+	req.Header.Set(headerSignature, s.label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// params returns the serialized signature parameters covering the
+// components.
+func (s *Signer) params(components []string) string {
+	quoted := make([]string, len(components))
+	for i, c := range components {
+		quoted[i] = sfString(c)
+	}
+
+	created := s.now()
+	params := "(" + strings.Join(quoted, " ") + ");created=" + strconv.FormatInt(created.Unix(), 10)
+	if s.expires > 0 {
+		params += ";expires=" + strconv.FormatInt(created.Add(s.expires).Unix(), 10)
+	}
+	if s.nonce {
+		params += ";nonce=" + sfString(jwt.ID())
+	}
+	params += ";keyid=" + sfString(s.keyID)
+	if s.tag != "" {
+		params += ";tag=" + sfString(s.tag)
+	}
+	return params
+}
+
+// contentDigest returns the Content-Digest header value of the request body.
+func (s *Signer) contentDigest(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", errBodyNotReset
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	h := digests[s.digest]()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return s.digest + "=:" + base64.StdEncoding.EncodeToString(h.Sum(nil)) + ":", nil
+}
+
+// derive holds the functions computing the supported derived components.
+var derive = map[string]func(*http.Request) string{
+	"@method": func(req *http.Request) string {
+		return strings.ToUpper(req.Method)
+	},
+	"@target-uri": func(req *http.Request) string {
+		return strings.ToLower(req.URL.Scheme) + "://" + authority(req) + req.URL.RequestURI()
+	},
+	"@authority": authority,
+	"@scheme": func(req *http.Request) string {
+		return strings.ToLower(req.URL.Scheme)
+	},
+	"@request-target": func(req *http.Request) string {
+		return req.URL.RequestURI()
+	},
+	"@path": func(req *http.Request) string {
+		if p := req.URL.EscapedPath(); p != "" {
+			return p
+		}
+		return "/"
+	},
+	"@query": func(req *http.Request) string {
+		return "?" + req.URL.RawQuery
+	},
+}
+
+// authority returns the host of the request without the default port.
+func authority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+
+	switch {
+	case strings.EqualFold(req.URL.Scheme, "https"):
+		return strings.TrimSuffix(host, ":443")
+	case strings.EqualFold(req.URL.Scheme, "http"):
+		return strings.TrimSuffix(host, ":80")
+	}
+	return host
+}
+
+// componentValue returns the value of a component of the request.
+func componentValue(req *http.Request, name string) (string, error) {
+	if fn := derive[name]; fn != nil {
+		return fn(req), nil
+	}
+
+	values := req.Header.Values(name)
+	if len(values) == 0 {
+		// Go keeps these outside of the header map.
+		switch {
+		case name == "host":
+			return authority(req), nil
+		case name == "content-length" && req.ContentLength >= 0 && req.Body != nil && req.Body != http.NoBody:
+			return strconv.FormatInt(req.ContentLength, 10), nil
+		}
+		return "", fmt.Errorf("%w: %s", errMissingHeader, name)
+	}
+
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+// sfString serializes a structured field string as described in RFC 8941.
+func sfString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// InsecureTransportError is returned when credentials would be sent to a
+// destination that does not use TLS and is not listed in InsecureHosts.
+type InsecureTransportError struct {
+	// URL is the destination, without any query or user information.
+	URL string
+}
+
+func (e *InsecureTransportError) Error() string {
+	return "refusing to send credentials without TLS to " + e.URL
+}
+
+// checkTransport returns an *InsecureTransportError if credentials may not be
+// sent to the URL.
+func (a *Vouch) checkTransport(u *url.URL) error {
+	if strings.EqualFold(u.Scheme, "https") {
+		return nil
+	}
+	if slices.Contains(a.insecureHosts, "*") || hostListed(a.insecureHosts, u) {
+		return nil
+	}
+	return &InsecureTransportError{
+		URL: destination(u),
+	}
+}
+
+// destination describes where a URL points to without exposing its query or
+// user information, which may hold credentials.
+func destination(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
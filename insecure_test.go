@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/oauth"
+)
+
+func TestVouch_DecorateInsecure(t *testing.T) {
+	tests := []struct {
+		description   string
+		insecureHosts []string
+		url           string
+		expectError   bool
+		expectURL     string
+	}{
+		{
+			description: "https is allowed",
+			url:         "https://example.com/",
+		},
+		{
+			description: "http is refused by default",
+			url:         "http://example.com/path?api_key=secret",
+			expectError: true,
+			expectURL:   "http://example.com/path",
+		},
+		{
+			description:   "Listed host",
+			insecureHosts: []string{"LOCALHOST"},
+			url:           "http://localhost:8080/",
+		},
+		{
+			description:   "Listed host and port",
+			insecureHosts: []string{"127.0.0.1:8080"},
+			url:           "http://127.0.0.1:8080/",
+		},
+		{
+			description:   "Listed host on another port",
+			insecureHosts: []string{"127.0.0.1:8080"},
+			url:           "http://127.0.0.1:9090/",
+			expectError:   true,
+			expectURL:     "http://127.0.0.1:9090/",
+		},
+		{
+			description:   "Any host",
+			insecureHosts: []string{"*"},
+			url:           "http://example.com/",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var got []events.DecorateEvent
+			v, err := New(Config{
+				Basic:         basic.Config{Username: "user", Password: "pass"},
+				InsecureHosts: tc.insecureHosts,
+			}, WithDecorateEventListener(events.DecorateEventListenerFunc(func(e events.DecorateEvent) {
+				got = append(got, e)
+			})))
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			err = v.Decorate(req)
+			if !tc.expectError {
+				if err != nil {
+					t.Errorf("did not expect an error but got: %v", err)
+				}
+				return
+			}
+
+			var insecure *InsecureTransportError
+			if !errors.As(err, &insecure) {
+				t.Fatalf("expected an InsecureTransportError but got: %v", err)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Errorf("expected no credentials on the request")
+			}
+			if len(got) != 1 || !errors.As(got[0].Err, &insecure) {
+				t.Errorf("expected a decorate event with the error but got: %+v", got)
+			}
+			if insecure.URL != tc.expectURL {
+				t.Errorf("unexpected URL in the error: %q", insecure.URL)
+			}
+		})
+	}
+}
+
+func TestVouch_NewInsecureTokenURL(t *testing.T) {
+	tests := []struct {
+		description   string
+		config        Config
+		insecureHosts []string
+		expectError   bool
+	}{
+		{
+			description: "https TokenURL",
+			config: Config{
+				OAuth: oauth.Config{
+					ClientID: "test-client",
+					TokenURL: "https://example.com/token",
+				},
+			},
+		},
+		{
+			description: "http TokenURL",
+			config: Config{
+				OAuth: oauth.Config{
+					ClientID: "test-client",
+					TokenURL: "http://example.com/token",
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "http TokenURL of a route",
+			config: Config{
+				Routes: []Route{
+					{
+						OAuth: oauth.Config{
+							ClientID: "test-client",
+							TokenURL: "http://example.com/token",
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "http TokenURL of a listed host",
+			config: Config{
+				OAuth: oauth.Config{
+					ClientID: "test-client",
+					TokenURL: "http://example.com/token",
+				},
+				InsecureHosts: []string{"example.com"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := New(tc.config)
+			var insecure *InsecureTransportError
+			if tc.expectError != errors.As(err, &insecure) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
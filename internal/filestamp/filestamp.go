@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filestamp notices changes to the files the decorators read again
+// when they change, such as rotated keys, certificates and credentials,
+// without reading them.
+package filestamp
+
+import (
+	"os"
+	"time"
+)
+
+// Stamp identifies a version of a file. The zero Stamp matches no file that
+// has been stat'ed.
+type Stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Stat returns the stamp of the current version of a file.
+func Stat(path string) (Stamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Stamp{}, err
+	}
+	return Stamp{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+	}, nil
+}
+
+// Equal reports whether both stamps identify the same version of a file.
+func (s Stamp) Equal(o Stamp) bool {
+	return s.modTime.Equal(o.modTime) && s.size == o.size
+}
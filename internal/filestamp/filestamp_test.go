@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package filestamp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("one"), 0600))
+
+	first, err := Stat(path)
+	require.NoError(t, err)
+	assert.False(t, first.Equal(Stamp{}))
+
+	again, err := Stat(path)
+	require.NoError(t, err)
+	assert.True(t, first.Equal(again))
+
+	// A change of size is noticed even within the resolution of the
+	// modification time.
+	require.NoError(t, os.WriteFile(path, []byte("three"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Time{}, first.modTime))
+	changed, err := Stat(path)
+	require.NoError(t, err)
+	assert.False(t, first.Equal(changed))
+
+	// So is a change of modification time with the same size.
+	require.NoError(t, os.Chtimes(path, time.Time{}, first.modTime.Add(time.Second)))
+	touched, err := Stat(path)
+	require.NoError(t, err)
+	assert.False(t, changed.Equal(touched))
+
+	_, err = Stat(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jwt signs and inspects the JSON Web Tokens used by the vouch
+// decorators. It does not verify tokens; that is left to the servers that
+// receive them.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoExpiry is returned by Expiry for a JWT without an exp claim.
+var ErrNoExpiry = errors.New("JWT has no exp claim")
+
+var (
+	errNoPEM          = errors.New("no PEM encoded key found")
+	errUnsupportedKey = errors.New("unsupported key type")
+	errMalformed      = errors.New("malformed JWT")
+)
+
+// ParsePrivateKey parses a PEM encoded RSA, ECDSA or Ed25519 private key in
+// PKCS #8, PKCS #1 or SEC 1 form.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, rest := pem.Decode(data)
+	for block != nil && !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		block, rest = pem.Decode(rest)
+	}
+	if block == nil {
+		return nil, errNoPEM
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return k.(crypto.Signer), nil
+	}
+	return nil, errUnsupportedKey
+}
+
+// Algorithm returns the JWS algorithm used to sign with the key.
+func Algorithm(key crypto.Signer) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+	case ed25519.PrivateKey:
+		return "EdDSA", nil
+	}
+	return "", errUnsupportedKey
+}
+
+// Sign returns the compact serialization of a JWS over the claims. The alg
+// and typ header parameters are set unless present in header.
+func Sign(key crypto.Signer, header, claims map[string]any) (string, error) {
+	alg, err := Algorithm(key)
+	if err != nil {
+		return "", err
+	}
+
+	h := map[string]any{
+		"alg": alg,
+		"typ": "JWT",
+	}
+	for k, v := range header {
+		h[k] = v
+	}
+
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	cb, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	input := encode(hb) + "." + encode(cb)
+	sig, err := signature(key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+
+	return input + "." + encode(sig), nil
+}
+
+// signature signs the input as required by the JWS algorithm of the key.
+func signature(key crypto.Signer, input []byte) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256(input)
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var digest []byte
+		switch k.Curve {
+		case elliptic.P256():
+			d := sha256.Sum256(input)
+			digest = d[:]
+		case elliptic.P384():
+			d := sha512.Sum384(input)
+			digest = d[:]
+		default:
+			d := sha512.Sum512(input)
+			digest = d[:]
+		}
+		return SignECDSA(k, digest)
+	case ed25519.PrivateKey:
+		return k.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+	return nil, errUnsupportedKey
+}
+
+// SignECDSA signs a digest with an ECDSA key, returning the fixed size
+// concatenation of r and s used by JWS and HTTP Message Signatures.
+func SignECDSA(k *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+	if err != nil {
+		return nil, err
+	}
+	size := (k.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+// Claims decodes the claims of a JWT without verifying its signature.
+func Claims(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformed
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformed, err)
+	}
+
+	var claims map[string]any
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformed, err)
+	}
+	return claims, nil
+}
+
+// Expiry returns the time of the exp claim of a JWT without verifying its
+// signature.
+func Expiry(token string) (time.Time, error) {
+	claims, err := Claims(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, ErrNoExpiry
+	}
+	return time.Unix(int64(exp), 0), nil
+}
+
+// ID returns a random identifier suitable for the jti claim.
+func ID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return encode(b)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
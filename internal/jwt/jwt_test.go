@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		data        []byte
+		expectAlg   string
+		expectError bool
+	}{
+		{
+			description: "PKCS #1 RSA key",
+			data:        pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			expectAlg:   "RS256",
+		},
+		{
+			description: "SEC 1 ECDSA key after EC parameters",
+			data: append(
+				pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x08}}),
+				pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})...,
+			),
+			expectAlg: "ES256",
+		},
+		{
+			description: "PKCS #8 Ed25519 key",
+			data:        pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+			expectAlg:   "EdDSA",
+		},
+		{
+			description: "Not PEM",
+			data:        []byte("not a key"),
+			expectError: true,
+		},
+		{
+			description: "Corrupt key",
+			data:        pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("corrupt")}),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			key, err := ParsePrivateKey(tc.data)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			alg, err := Algorithm(key)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectAlg, alg)
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		key         crypto.Signer
+		expectAlg   string
+	}{
+		{description: "RSA", key: rsaKey, expectAlg: "RS256"},
+		{description: "ECDSA P-256", key: p256, expectAlg: "ES256"},
+		{description: "ECDSA P-384", key: p384, expectAlg: "ES384"},
+		{description: "ECDSA P-521", key: p521, expectAlg: "ES512"},
+		{description: "Ed25519", key: edKey, expectAlg: "EdDSA"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			exp := time.Now().Add(time.Minute).Truncate(time.Second)
+			token, err := Sign(tc.key,
+				map[string]any{"kid": "key-1"},
+				map[string]any{"iss": "me", "exp": exp.Unix()},
+			)
+			require.NoError(t, err)
+
+			parts := strings.Split(token, ".")
+			require.Len(t, parts, 3)
+
+			var header map[string]any
+			data, err := base64.RawURLEncoding.DecodeString(parts[0])
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(data, &header))
+			assert.Equal(t, tc.expectAlg, header["alg"])
+			assert.Equal(t, "JWT", header["typ"])
+			assert.Equal(t, "key-1", header["kid"])
+
+			sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+			require.NoError(t, err)
+			assert.True(t, verify(tc.key.Public(), []byte(parts[0]+"."+parts[1]), sig))
+
+			claims, err := Claims(token)
+			require.NoError(t, err)
+			assert.Equal(t, "me", claims["iss"])
+
+			got, err := Expiry(token)
+			require.NoError(t, err)
+			assert.True(t, exp.Equal(got))
+		})
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	tests := []struct {
+		description string
+		token       string
+	}{
+		{description: "Not a JWT", token: "opaque"},
+		{description: "Invalid encoding", token: "a.!!!.c"},
+		{description: "Invalid JSON", token: "a." + base64.RawURLEncoding.EncodeToString([]byte("{")) + ".c"},
+		{description: "No exp claim", token: "a." + base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"me"}`)) + ".c"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := Expiry(tc.token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestID(t *testing.T) {
+	assert.NotEqual(t, ID(), ID())
+}
+
+// verify checks a JWS signature with the public key.
+func verify(pub crypto.PublicKey, input, sig []byte) bool {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch k.Curve {
+		case elliptic.P256():
+			d := sha256.Sum256(input)
+			digest = d[:]
+		case elliptic.P384():
+			d := sha512.Sum384(input)
+			digest = d[:]
+		default:
+			d := sha512.Sum512(input)
+			digest = d[:]
+		}
+		size := len(sig) / 2
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, input, sig)
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tlstransport provides an HTTP transport whose TLS configuration
+// can change, such as when the CA certificates it trusts are rotated.
+package tlstransport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"sync"
+)
+
+// Transport is a clone of http.DefaultTransport using the TLS configuration
+// returned by a function. The configuration is obtained again for each
+// request and the underlying transport is rebuilt when its RootCAs change,
+// so servers are always verified against the current CA certificates.
+type Transport struct {
+	config func() *tls.Config
+
+	mu    sync.Mutex
+	roots *x509.CertPool
+	base  *http.Transport
+}
+
+// New creates a Transport using the TLS configuration returned by config.
+func New(config func() *tls.Config) *Transport {
+	return &Transport{
+		config: config,
+	}
+}
+
+// RoundTrip sends the request with the transport of the current TLS
+// configuration.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.base != nil {
+		t.base.CloseIdleConnections()
+	}
+}
+
+func (t *Transport) current() *http.Transport {
+	cfg := t.config()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.base != nil && cfg.RootCAs == t.roots {
+		return t.base
+	}
+
+	if t.base != nil {
+		// Connections verified with the old CA certificates are not reused.
+		t.base.CloseIdleConnections()
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = cfg
+	t.base, t.roots = base, cfg.RootCAs
+	return base
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package tlstransport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+	untrusted := x509.NewCertPool()
+
+	roots := untrusted
+	transport := New(func() *tls.Config {
+		return &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	})
+	client := &http.Client{Transport: transport}
+
+	_, err := client.Get(server.URL)
+	assert.Error(t, err)
+	first := transport.current()
+	assert.Same(t, first, transport.current(), "the transport is kept while the roots are unchanged")
+
+	// The transport is rebuilt with the new roots.
+	roots = trusted
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotSame(t, first, transport.current())
+	assert.Same(t, trusted, transport.current().TLSClientConfig.RootCAs)
+
+	transport.CloseIdleConnections()
+}
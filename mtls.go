@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/xmidt-org/vouch/internal/tlstransport"
+)
+
+// certifier is implemented by decorators whose credentials are bound to a TLS
+// client certificate, such as OAuth tokens issued over mutual TLS.
+type certifier interface {
+	TLSConfig() *tls.Config
+}
+
+// TLSConfig returns the TLS configuration of the highest priority default
+// decorator that presents a client certificate, or nil if none do. Servers
+// that accept certificate-bound access tokens must be called with it; Client
+// uses it when the base client has no Transport.
+//
+// Routes are not considered since the configuration cannot depend on the
+// destination of the request.
+func (a *Vouch) TLSConfig() *tls.Config {
+	for _, d := range a.decorators {
+		if c, ok := d.(certifier); ok {
+			if cfg := c.TLSConfig(); cfg != nil {
+				return cfg
+			}
+		}
+	}
+	return nil
+}
+
+// transport returns a clone of http.DefaultTransport that presents the client
+// certificate of the Vouch, or nil if there is none. The TLS configuration is
+// obtained again for each request so rotated CA certificates are trusted.
+func (a *Vouch) transport() http.RoundTripper {
+	if a.TLSConfig() == nil {
+		return nil
+	}
+	return tlstransport.New(a.TLSConfig)
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/xmidt-org/vouch/internal/tlstransport"
+)
+
+func TestVouch_TLSConfig(t *testing.T) {
+	cfg := &tls.Config{ServerName: "example.com"}
+
+	tests := []struct {
+		description string
+		decorators  []decorator
+		base        *http.Client
+		expectTLS   bool
+		expectBase  bool
+	}{
+		{
+			description: "No decorators",
+		},
+		{
+			description: "No certificate",
+			decorators:  []decorator{mockDecorator{}, mockCertifier{}},
+		},
+		{
+			description: "First decorator with a certificate",
+			decorators:  []decorator{mockDecorator{}, mockCertifier{}, mockCertifier{cfg: cfg}},
+			expectTLS:   true,
+		},
+		{
+			description: "Base client with a transport",
+			decorators:  []decorator{mockCertifier{cfg: cfg}},
+			base:        &http.Client{Transport: http.DefaultTransport},
+			expectTLS:   true,
+			expectBase:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			v := &Vouch{
+				decorators: tc.decorators,
+			}
+
+			got := v.TLSConfig()
+			if (got == cfg) != tc.expectTLS {
+				t.Errorf("unexpected TLS config: %v", got)
+			}
+
+			base := v.Client(tc.base).Transport.(*Transport).Base
+			switch {
+			case tc.expectBase:
+				if base != tc.base.Transport {
+					t.Errorf("expected the base transport to be kept")
+				}
+			case tc.expectTLS:
+				if _, ok := base.(*tlstransport.Transport); !ok {
+					t.Errorf("expected a transport presenting the certificate but got %v", base)
+				}
+			case base != nil:
+				t.Errorf("expected no base transport but got %v", base)
+			}
+		})
+	}
+}
+
+type mockCertifier struct {
+	mockDecorator
+	cfg *tls.Config
+}
+
+func (m mockCertifier) TLSConfig() *tls.Config {
+	return m.cfg
+}
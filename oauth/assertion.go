@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/vouch/internal/filestamp"
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// defaultAssertionLifetime is how long a signed assertion is valid for when
+// Assertion.Lifetime is not set.
+const defaultAssertionLifetime = 5 * time.Minute
+
+// clientAssertionType identifies a JWT client assertion.
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+var errNoIssuer = errors.New("assertion issuer is required when there is no ClientID")
+
+// Assertion configures the signed JWTs sent to the token endpoint. The key
+// file is read again when it changes, so keys can be rotated in place.
+type Assertion struct {
+	// KeyFile is the path of the PEM encoded RSA, ECDSA or Ed25519 private
+	// key used to sign the assertion.
+	KeyFile string
+
+	// KeyID is the optional kid header of the assertion.
+	KeyID string
+
+	// Issuer is the iss claim. The default is the ClientID.
+	Issuer string
+
+	// Subject is the sub claim. The default is the Issuer.
+	Subject string
+
+	// Audience is the aud claim. The default is the TokenURL.
+	Audience string
+
+	// Lifetime is how long each assertion is valid for. The default is
+	// 5 minutes.
+	Lifetime time.Duration
+}
+
+// assertion signs a new JWT for every token request.
+type assertion struct {
+	key      *keyFile
+	keyID    string
+	issuer   string
+	subject  string
+	audience string
+	lifetime time.Duration
+}
+
+func newAssertion(c Assertion, clientID, tokenURL string) (*assertion, error) {
+	if c.KeyFile == "" {
+		return nil, errors.New("assertion KeyFile is required")
+	}
+
+	key := &keyFile{path: c.KeyFile}
+	if _, err := key.signer(); err != nil {
+		return nil, err
+	}
+
+	a := assertion{
+		key:      key,
+		keyID:    c.KeyID,
+		issuer:   c.Issuer,
+		subject:  c.Subject,
+		audience: c.Audience,
+		lifetime: c.Lifetime,
+	}
+	if a.issuer == "" {
+		a.issuer = clientID
+	}
+	if a.issuer == "" {
+		return nil, errNoIssuer
+	}
+	if a.subject == "" {
+		a.subject = a.issuer
+	}
+	if a.audience == "" {
+		a.audience = tokenURL
+	}
+	if a.lifetime <= 0 {
+		a.lifetime = defaultAssertionLifetime
+	}
+
+	return &a, nil
+}
+
+// forClient returns an assertion that authenticates the client, as required
+// by the private_key_jwt AuthStyle.
+func (a *assertion) forClient(clientID string) *assertion {
+	c := *a
+	c.issuer = clientID
+	c.subject = clientID
+	return &c
+}
+
+// sign returns a new assertion with a unique jti claim.
+func (a *assertion) sign() (string, error) {
+	key, err := a.key.signer()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	var header map[string]any
+	if a.keyID != "" {
+		header = map[string]any{"kid": a.keyID}
+	}
+
+	return jwt.Sign(key, header, map[string]any{
+		"iss": a.issuer,
+		"sub": a.subject,
+		"aud": a.audience,
+		"iat": now.Unix(),
+		"exp": now.Add(a.lifetime).Unix(),
+		"jti": jwt.ID(),
+	})
+}
+
+// keyFile is a private key that is read again whenever its file changes, so
+// keys can be rotated without restarting.
+type keyFile struct {
+	path string
+
+	mu    sync.Mutex
+	stamp filestamp.Stamp
+	key   crypto.Signer
+}
+
+func (k *keyFile) signer() (crypto.Signer, error) {
+	stamp, err := filestamp.Stat(k.path)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.key != nil && stamp.Equal(k.stamp) {
+		return k.key, nil
+	}
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwt.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion key %s: %w", k.path, err)
+	}
+
+	k.key, k.stamp = key, stamp
+	return key, nil
+}
+
+// signedFetch fetches tokens using signed assertions. The grant assertion is
+// sent with the JWT bearer grant described in RFC 7523 and the client
+// assertion authenticates the client as described in RFC 7523 section 2.2.
+// Either may be nil, and new assertions are signed for each request.
+func signedFetch(base func() *clientcredentials.Config, grant, client *assertion) func(context.Context) (*oauth2.Token, error) {
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		cfg := base()
+		params := url.Values{}
+		for k, v := range cfg.EndpointParams {
+			params[k] = v
+		}
+
+		if grant != nil {
+			signed, err := grant.sign()
+			if err != nil {
+				return nil, err
+			}
+			params.Set("grant_type", GrantJWTBearer)
+			params.Set("assertion", signed)
+		}
+
+		if client != nil {
+			signed, err := client.sign()
+			if err != nil {
+				return nil, err
+			}
+			params.Set("client_assertion_type", clientAssertionType)
+			params.Set("client_assertion", signed)
+		}
+
+		cfg.EndpointParams = params
+		return cfg.Token(ctx)
+	}
+}
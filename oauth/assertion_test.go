@@ -0,0 +1,379 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+// writeKey writes a new Ed25519 key to a PEM file and returns its path and
+// public key.
+func writeKey(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	return path, pub
+}
+
+// verifyAssertion checks the signature of an Ed25519 signed JWT and returns
+// its claims.
+func verifyAssertion(t *testing.T, pub ed25519.PublicKey, token string) map[string]any {
+	t.Helper()
+
+	i := strings.LastIndex(token, ".")
+	require.Positive(t, i)
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(pub, []byte(token[:i]), sig), "invalid assertion signature")
+
+	claims, err := jwt.Claims(token)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestNewJWTBearer(t *testing.T) {
+	keyFile, _ := writeKey(t)
+
+	tests := []struct {
+		description string
+		config      Config
+		expectNil   bool
+		expectError bool
+	}{
+		{
+			description: "Active without a ClientID",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				TokenURL:  "https://example.com/token",
+				Assertion: Assertion{KeyFile: keyFile, Issuer: "me"},
+			},
+		},
+		{
+			description: "Inactive without a TokenURL",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				Assertion: Assertion{KeyFile: keyFile, Issuer: "me"},
+			},
+			expectNil: true,
+		},
+		{
+			description: "Invalid GrantType",
+			config: Config{
+				GrantType: "password",
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+			},
+			expectError: true,
+		},
+		{
+			description: "Missing KeyFile",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+			},
+			expectError: true,
+		},
+		{
+			description: "Unreadable KeyFile",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+				Assertion: Assertion{KeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+			},
+			expectError: true,
+		},
+		{
+			description: "Invalid KeyFile",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+				Assertion: Assertion{KeyFile: "assertion.go"},
+			},
+			expectError: true,
+		},
+		{
+			description: "Missing issuer",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				TokenURL:  "https://example.com/token",
+				Assertion: Assertion{KeyFile: keyFile},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := New(tc.config, nil)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectNil, got == nil)
+		})
+	}
+}
+
+func TestOAuthJWTBearer(t *testing.T) {
+	keyFile, pub := writeKey(t)
+
+	tests := []struct {
+		description    string
+		config         Assertion
+		clientID       string
+		expectIssuer   string
+		expectSubject  string
+		expectAudience string
+		expectLifetime time.Duration
+	}{
+		{
+			description:    "Defaults from the client",
+			config:         Assertion{KeyFile: keyFile},
+			clientID:       "test-client",
+			expectIssuer:   "test-client",
+			expectSubject:  "test-client",
+			expectLifetime: defaultAssertionLifetime,
+		},
+		{
+			description: "Configured claims",
+			config: Assertion{
+				KeyFile:  keyFile,
+				KeyID:    "key-1",
+				Issuer:   "issuer",
+				Subject:  "subject",
+				Audience: "https://idp.example.com",
+				Lifetime: time.Minute,
+			},
+			expectIssuer:   "issuer",
+			expectSubject:  "subject",
+			expectAudience: "https://idp.example.com",
+			expectLifetime: time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var jtis []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, GrantJWTBearer, r.PostForm.Get("grant_type"))
+				assert.Equal(t, "read", r.PostForm.Get("scope"))
+				assert.Equal(t, tc.clientID, r.PostForm.Get("client_id"))
+				_, _, ok := r.BasicAuth()
+				assert.False(t, ok)
+
+				claims := verifyAssertion(t, pub, r.PostForm.Get("assertion"))
+				assert.Equal(t, tc.expectIssuer, claims["iss"])
+				assert.Equal(t, tc.expectSubject, claims["sub"])
+				aud := tc.expectAudience
+				if aud == "" {
+					aud = "http://" + r.Host
+				}
+				assert.Equal(t, aud, claims["aud"])
+				iat, _ := claims["iat"].(float64)
+				exp, _ := claims["exp"].(float64)
+				assert.Equal(t, tc.expectLifetime, time.Duration(exp-iat)*time.Second)
+				jti, _ := claims["jti"].(string)
+				assert.NotEmpty(t, jti)
+				assert.NotContains(t, jtis, jti)
+				jtis = append(jtis, jti)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token": "mock-token", "token_type": "Bearer", "expires_in": 3600}`))
+			}))
+			defer server.Close()
+
+			var fetches []events.FetchEvent
+			o, err := New(Config{
+				GrantType: GrantJWTBearer,
+				ClientID:  tc.clientID,
+				TokenURL:  server.URL,
+				Scopes:    []string{"read"},
+				Assertion: tc.config,
+			}, func(e any) {
+				if f, ok := e.(events.FetchEvent); ok {
+					fetches = append(fetches, f)
+				}
+			})
+			require.NoError(t, err)
+			require.NotNil(t, o)
+
+			req, err := http.NewRequest("GET", "https://example.com/resource", nil)
+			require.NoError(t, err)
+			require.NoError(t, o.Decorate(req))
+			assert.Equal(t, "Bearer mock-token", req.Header.Get("Authorization"))
+
+			// A rejected token is replaced using a new assertion.
+			resp := &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Header:     http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}},
+			}
+			require.True(t, o.Challenge(req, resp))
+			require.NoError(t, o.Decorate(req))
+
+			assert.Len(t, jtis, 2)
+			require.Len(t, fetches, 2)
+			assert.NoError(t, fetches[0].Err)
+			assert.True(t, fetches[1].Forced)
+		})
+	}
+}
+
+func TestNewPrivateKeyJWT(t *testing.T) {
+	keyFile, _ := writeKey(t)
+
+	tests := []struct {
+		description string
+		config      Config
+		expectError bool
+	}{
+		{
+			description: "Valid configuration",
+			config: Config{
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+				AuthStyle: "private_key_jwt",
+				Assertion: Assertion{KeyFile: keyFile},
+			},
+		},
+		{
+			description: "With a jwt-bearer grant",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+				AuthStyle: "private_key_jwt",
+				Assertion: Assertion{KeyFile: keyFile, Subject: "user"},
+			},
+		},
+		{
+			description: "Missing ClientID",
+			config: Config{
+				GrantType: GrantJWTBearer,
+				TokenURL:  "https://example.com/token",
+				AuthStyle: "private_key_jwt",
+				Assertion: Assertion{KeyFile: keyFile, Issuer: "me"},
+			},
+			expectError: true,
+		},
+		{
+			description: "With a ClientSecret",
+			config: Config{
+				ClientID:     "test-client",
+				ClientSecret: "test-secret",
+				TokenURL:     "https://example.com/token",
+				AuthStyle:    "private_key_jwt",
+				Assertion:    Assertion{KeyFile: keyFile},
+			},
+			expectError: true,
+		},
+		{
+			description: "Missing KeyFile",
+			config: Config{
+				ClientID:  "test-client",
+				TokenURL:  "https://example.com/token",
+				AuthStyle: "private_key_jwt",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := New(tc.config, nil)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestOAuthPrivateKeyJWT(t *testing.T) {
+	keyFile, pub := writeKey(t)
+
+	var jtis []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "test-client", r.PostForm.Get("client_id"))
+		assert.False(t, r.PostForm.Has("client_secret"))
+		assert.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+
+		claims := verifyAssertion(t, pub, r.PostForm.Get("client_assertion"))
+		assert.Equal(t, "test-client", claims["iss"])
+		assert.Equal(t, "test-client", claims["sub"])
+		assert.Equal(t, "http://"+r.Host, claims["aud"])
+		jti, _ := claims["jti"].(string)
+		assert.NotContains(t, jtis, jti)
+		jtis = append(jtis, jti)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "mock-token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	o, err := New(Config{
+		ClientID:  "test-client",
+		TokenURL:  server.URL,
+		AuthStyle: "private_key_jwt",
+		Assertion: Assertion{KeyFile: keyFile},
+	}, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "https://example.com/resource", nil)
+	require.NoError(t, err)
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer mock-token", req.Header.Get("Authorization"))
+
+	// Rotate the key in place; the next fetch signs with the new key.
+	rotated, newPub := writeKey(t)
+	data, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, data, 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	pub = newPub
+
+	resp := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": {"Bearer"}},
+	}
+	require.True(t, o.Challenge(req, resp))
+	require.NoError(t, o.Decorate(req))
+	assert.Len(t, jtis, 2)
+
+	// A key that can no longer be read fails the fetch.
+	require.NoError(t, os.Remove(keyFile))
+	require.True(t, o.Challenge(req, resp))
+	assert.Error(t, o.Decorate(req))
+}
@@ -4,14 +4,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/xmidt-org/vouch/events"
 	"github.com/xmidt-org/vouch/oauth"
+	"golang.org/x/oauth2"
 )
 
 func main() {
@@ -28,22 +33,109 @@ func main() {
 		Scopes:       scopes,
 	}
 
-	o, err := oauth.New(config, listen)
+	config.DeviceAuthURL = strings.TrimSpace(os.Getenv("OAUTH_DEVICE_URL"))
+	config.AuthURL = strings.TrimSpace(os.Getenv("OAUTH_AUTH_URL"))
+	if config.DeviceAuthURL != "" || config.AuthURL != "" {
+		if err := login(&config, false); err != nil {
+			panic(err)
+		}
+	}
+
+	req, err := decorate(config)
+
+	// A refresh token that was revoked or has expired cannot be redeemed
+	// again, so the user logs in again to replace the cached tokens.
+	var re *oauth2.RetrieveError
+	if config.Profile != "" && errors.As(err, &re) && re.ErrorCode == "invalid_grant" {
+		if err = login(&config, true); err == nil {
+			req, err = decorate(config)
+		}
+	}
 	if err != nil {
 		panic(err)
 	}
 
+	fmt.Printf("Request Headers: %v\n", req.Header)
+}
+
+// decorate returns a request decorated with the credentials of the
+// configuration.
+func decorate(config oauth.Config) (*http.Request, error) {
+	o, err := oauth.New(config, listen)
+	if err != nil {
+		return nil, err
+	}
+
 	req, err := http.NewRequest("GET", "https://example.com", nil)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if err := o.Decorate(req); err != nil {
+		return nil, err
 	}
+	return req, nil
+}
 
-	err = o.Decorate(req)
+// login switches the configuration to the tokens cached for the
+// OAUTH_PROFILE, logging in as the user when there are none or force is
+// set. The same tokens are used by any oauth.Config with the refresh_token
+// grant and this Profile.
+func login(config *oauth.Config, force bool) error {
+	config.Profile = strings.TrimSpace(os.Getenv("OAUTH_PROFILE"))
+	if config.Profile == "" {
+		config.Profile = "default"
+	}
+
+	file, err := oauth.ProfileFile(config.Profile)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	store := oauth.FileStore(file)
+	set, err := store.Read()
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Request Headers: %v\n", req.Header)
+	if force || (set.RefreshToken == "" && !time.Now().Before(set.Expiry)) {
+		if config.AuthURL != "" {
+			set, err = oauth.LoopbackLogin(context.Background(), *config, openBrowser)
+		} else {
+			set, err = oauth.DeviceLogin(context.Background(), *config, func(code oauth.DeviceCode) {
+				fmt.Fprintf(os.Stderr, "To sign in, open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+				if code.VerificationURIComplete != "" {
+					fmt.Fprintf(os.Stderr, "or open %s\n", code.VerificationURIComplete)
+				}
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if err := store.Write(set); err != nil {
+			return err
+		}
+	}
+
+	config.GrantType = oauth.GrantRefreshToken
+	return nil
+}
+
+// openBrowser prints the URL and tries to open it in the user's browser.
+func openBrowser(u string) {
+	fmt.Fprintf(os.Stderr, "To sign in, open %s\n", u)
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
 }
 
 func listen(evnt any) {
@@ -54,6 +146,7 @@ func listen(evnt any) {
 		fmt.Printf("  Type:       %s\n", e.Type)
 		fmt.Printf("  Duration:   %s\n", e.Duration)
 		fmt.Printf("  Expiration: %s\n", e.Expiration.Format(time.RFC3339))
+		fmt.Printf("  Rotated:    %t\n", e.Rotated)
 		fmt.Printf("  Error:      %v\n", e.Err)
 	case events.DecorateEvent:
 		fmt.Println("Decorate Event:")
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// GrantDeviceCode is the grant used by DeviceLogin to poll for the tokens.
+const GrantDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// defaultDeviceInterval is how often the token endpoint is polled when the
+// server does not say; slow_down adds the same amount.
+const defaultDeviceInterval = 5 * time.Second
+
+var (
+	errNoDeviceAuthURL   = errors.New("DeviceAuthURL and TokenURL are required for a device login")
+	errDeviceCodeExpired = errors.New("the device code expired before the login was approved")
+)
+
+// DeviceCode is what the user needs to approve a device login.
+type DeviceCode struct {
+	// UserCode is the code the user enters at the VerificationURI.
+	UserCode string
+
+	// VerificationURI is where the user approves the login.
+	VerificationURI string
+
+	// VerificationURIComplete is the optional VerificationURI that already
+	// includes the UserCode.
+	VerificationURIComplete string
+
+	// Expiry is when the codes stop being accepted.
+	Expiry time.Time
+}
+
+// DeviceLogin runs the device authorization grant of RFC 8628 with the
+// DeviceAuthURL, TokenURL, ClientID, ClientSecret, AuthStyle and Scopes of
+// the configuration. The prompt is called with the code the user must
+// approve, then the token endpoint is polled until the user approves or
+// denies the login, the code expires or the context is canceled.
+//
+// The tokens can be saved to a FileStore and reused with the refresh_token
+// grant.
+func DeviceLogin(ctx context.Context, config Config, prompt func(DeviceCode)) (TokenSet, error) {
+	return deviceLogin(ctx, config, prompt, time.After)
+}
+
+func deviceLogin(ctx context.Context, config Config, prompt func(DeviceCode), after func(time.Duration) <-chan time.Time) (TokenSet, error) {
+	if config.DeviceAuthURL == "" || config.TokenURL == "" {
+		return TokenSet{}, errNoDeviceAuthURL
+	}
+
+	style, ok := styleMap[config.AuthStyle]
+	if !ok {
+		return TokenSet{}, fmt.Errorf("invalid AuthStyle: %s", config.AuthStyle)
+	}
+	if config.ClientSecret == "" && style == oauth2.AuthStyleAutoDetect {
+		style = oauth2.AuthStyleInParams
+	}
+
+	device := oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Scopes:       config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			DeviceAuthURL: config.DeviceAuthURL,
+			TokenURL:      config.TokenURL,
+			AuthStyle:     style,
+		},
+	}
+
+	da, err := device.DeviceAuth(ctx)
+	if err != nil {
+		return TokenSet{}, err
+	}
+
+	prompt(DeviceCode{
+		UserCode:                da.UserCode,
+		VerificationURI:         da.VerificationURI,
+		VerificationURIComplete: da.VerificationURIComplete,
+		Expiry:                  da.Expiry,
+	})
+
+	params := url.Values{}
+	for k, v := range config.EndpointParams {
+		params[k] = v
+	}
+	params.Set("grant_type", GrantDeviceCode)
+	params.Set("device_code", da.DeviceCode)
+
+	poll := clientcredentials.Config{
+		ClientID:       config.ClientID,
+		ClientSecret:   config.ClientSecret,
+		TokenURL:       config.TokenURL,
+		EndpointParams: params,
+		AuthStyle:      style,
+	}
+
+	return pollDevice(ctx, &poll, da, after)
+}
+
+// pollDevice polls the token endpoint at the interval of the device
+// authorization response until the user approves or denies the login, the
+// code expires or the context is canceled. The interval grows on slow_down.
+func pollDevice(ctx context.Context, poll *clientcredentials.Config, da *oauth2.DeviceAuthResponse, after func(time.Duration) <-chan time.Time) (TokenSet, error) {
+	interval := time.Duration(da.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return TokenSet{}, ctx.Err()
+		case <-after(interval):
+		}
+
+		if !da.Expiry.IsZero() && time.Now().After(da.Expiry) {
+			return TokenSet{}, errDeviceCodeExpired
+		}
+
+		tok, err := poll.Token(ctx)
+		if err == nil {
+			return tokenSet(tok), nil
+		}
+
+		var re *oauth2.RetrieveError
+		if !errors.As(err, &re) {
+			return TokenSet{}, err
+		}
+		switch re.ErrorCode {
+		case "authorization_pending":
+		case "slow_down":
+			interval += defaultDeviceInterval
+		case "expired_token":
+			return TokenSet{}, errDeviceCodeExpired
+		default:
+			return TokenSet{}, err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestDeviceLogin(t *testing.T) {
+	tests := []struct {
+		description     string
+		interval        int
+		responses       []string
+		expectIntervals []time.Duration
+		expectError     error
+		expectErrorCode string
+	}{
+		{
+			description: "Approved",
+			interval:    2,
+			responses: []string{
+				`{"error": "authorization_pending"}`,
+				`{"error": "slow_down"}`,
+				`{"error": "authorization_pending"}`,
+			},
+			expectIntervals: []time.Duration{2 * time.Second, 2 * time.Second, 7 * time.Second, 7 * time.Second},
+		},
+		{
+			description:     "Default interval",
+			expectIntervals: []time.Duration{5 * time.Second},
+		},
+		{
+			description:     "Expired",
+			interval:        1,
+			responses:       []string{`{"error": "expired_token"}`},
+			expectIntervals: []time.Duration{time.Second},
+			expectError:     errDeviceCodeExpired,
+		},
+		{
+			description:     "Denied",
+			interval:        1,
+			responses:       []string{`{"error": "access_denied"}`},
+			expectIntervals: []time.Duration{time.Second},
+			expectErrorCode: "access_denied",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var polls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "cli", r.PostForm.Get("client_id"))
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case "/device":
+					assert.Equal(t, "openid offline_access", r.PostForm.Get("scope"))
+					fmt.Fprintf(w, `{"device_code": "device", "user_code": "ABCD-EFGH", "verification_uri": "https://example.com/device", "expires_in": 600, "interval": %d}`,
+						tc.interval)
+				case "/token":
+					assert.Equal(t, GrantDeviceCode, r.PostForm.Get("grant_type"))
+					assert.Equal(t, "device", r.PostForm.Get("device_code"))
+					assert.Empty(t, r.PostForm.Get("scope"))
+
+					polls++
+					if polls <= len(tc.responses) {
+						w.WriteHeader(http.StatusBadRequest)
+						w.Write([]byte(tc.responses[polls-1]))
+						return
+					}
+					w.Write([]byte(`{"access_token": "access", "token_type": "Bearer", "refresh_token": "refresh", "expires_in": 3600}`))
+				}
+			}))
+			defer server.Close()
+
+			var prompted []DeviceCode
+			var intervals []time.Duration
+			set, err := deviceLogin(context.Background(), Config{
+				ClientID:      "cli",
+				DeviceAuthURL: server.URL + "/device",
+				TokenURL:      server.URL + "/token",
+				Scopes:        []string{"openid", "offline_access"},
+			}, func(code DeviceCode) {
+				prompted = append(prompted, code)
+			}, func(d time.Duration) <-chan time.Time {
+				intervals = append(intervals, d)
+				c := make(chan time.Time, 1)
+				c <- time.Now()
+				return c
+			})
+
+			require.Len(t, prompted, 1)
+			assert.Equal(t, "ABCD-EFGH", prompted[0].UserCode)
+			assert.Equal(t, "https://example.com/device", prompted[0].VerificationURI)
+			assert.WithinDuration(t, time.Now().Add(10*time.Minute), prompted[0].Expiry, time.Minute)
+			assert.Equal(t, tc.expectIntervals, intervals)
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			if tc.expectErrorCode != "" {
+				var re *oauth2.RetrieveError
+				require.ErrorAs(t, err, &re)
+				assert.Equal(t, tc.expectErrorCode, re.ErrorCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access", set.AccessToken)
+			assert.Equal(t, "refresh", set.RefreshToken)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), set.Expiry, time.Minute)
+		})
+	}
+}
+
+func TestDeviceLoginCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"device_code": "device", "user_code": "ABCD", "verification_uri": "https://example.com/device"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := deviceLogin(ctx, Config{
+		ClientID:      "cli",
+		DeviceAuthURL: server.URL,
+		TokenURL:      server.URL,
+	}, func(DeviceCode) {
+		cancel()
+	}, func(time.Duration) <-chan time.Time {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = DeviceLogin(context.Background(), Config{TokenURL: server.URL}, func(DeviceCode) {})
+	assert.ErrorIs(t, err, errNoDeviceAuthURL)
+}
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	headerDPoP      = "DPoP"
+	headerDPoPNonce = "DPoP-Nonce"
+
+	// errUseDPoPNonce is the error servers return to require a nonce.
+	errUseDPoPNonce = "use_dpop_nonce"
+
+	// maxErrorBody is the most of a token error response that is read to
+	// look for a nonce error.
+	maxErrorBody = 64 << 10
+)
+
+var errDPoPHeaders = errors.New("DPoP proofs are bound to a request and cannot be provided as headers")
+
+// dpop creates the DPoP proofs described in RFC 9449 using a key pair that
+// lives as long as it does. It keeps the latest nonce issued by each server.
+type dpop struct {
+	key *ecdsa.PrivateKey
+	jwk map[string]any
+
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+func newDPoP() (*dpop, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	// The uncompressed point is 0x04 || x || y.
+	point := pub.Bytes()[1:]
+
+	return &dpop{
+		key: key,
+		jwk: map[string]any{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(point[:32]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[32:]),
+		},
+		nonces: map[string]string{},
+	}, nil
+}
+
+// proof returns a proof for a request. The access token is bound to the proof
+// when it is not empty.
+func (d *dpop) proof(method string, u *url.URL, accessToken string) (string, error) {
+	claims := map[string]any{
+		"jti": jwt.ID(),
+		"htm": method,
+		"htu": htu(u),
+		"iat": time.Now().Unix(),
+	}
+	if nonce := d.nonce(u); nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+
+	return jwt.Sign(d.key, map[string]any{
+		"typ": "dpop+jwt",
+		"jwk": d.jwk,
+	}, claims)
+}
+
+func (d *dpop) nonce(u *url.URL) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.nonces[origin(u)]
+}
+
+// updateNonce keeps the nonce of a response from the server, reporting
+// whether it is a new one.
+func (d *dpop) updateNonce(u *url.URL, resp *http.Response) bool {
+	nonce := resp.Header.Get(headerDPoPNonce)
+	if nonce == "" {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o := origin(u)
+	if d.nonces[o] == nonce {
+		return false
+	}
+	d.nonces[o] = nonce
+	return true
+}
+
+// htu returns the URI of a request without its query and fragment.
+func htu(u *url.URL) string {
+	return (&url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   u.Path,
+	}).String()
+}
+
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// dpopTransport sends a proof with each token request, and repeats a request
+// rejected because it lacks the current nonce of the server.
+type dpopTransport struct {
+	dpop *dpop
+	base http.RoundTripper
+}
+
+func (t *dpopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.send(req, req.Body)
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		return resp, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil || !bytes.Contains(data, []byte(errUseDPoPNonce)) || req.GetBody == nil {
+		return resp, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return resp, nil
+	}
+	return t.send(req, body)
+}
+
+func (t *dpopTransport) send(req *http.Request, body io.ReadCloser) (*http.Response, error) {
+	proof, err := t.dpop.proof(req.Method, req.URL, "")
+	if err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return nil, err
+	}
+
+	req2 := req.Clone(req.Context())
+	req2.Body = body
+	req2.Header.Set(headerDPoP, proof)
+
+	resp, err := t.base.RoundTrip(req2)
+	if err == nil {
+		t.dpop.updateNonce(req.URL, resp)
+	}
+	return resp, err
+}
+
+// withDPoP makes the fetch send proofs to the token endpoint, using the HTTP
+// client of the context if there is one.
+func withDPoP(fetch func(context.Context) (*oauth2.Token, error), d *dpop) func(context.Context) (*oauth2.Token, error) {
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		var client http.Client
+		if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && c != nil {
+			client = *c
+		}
+
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		client.Transport = &dpopTransport{
+			dpop: d,
+			base: base,
+		}
+
+		return fetch(context.WithValue(ctx, oauth2.HTTPClient, &client))
+	}
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+// verifyProof checks a DPoP proof against its embedded key and returns its
+// key and claims.
+func verifyProof(t *testing.T, proof string) (map[string]any, map[string]any) {
+	t.Helper()
+
+	parts := strings.Split(proof, ".")
+	require.Len(t, parts, 3)
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	var header struct {
+		Typ string         `json:"typ"`
+		Alg string         `json:"alg"`
+		JWK map[string]any `json:"jwk"`
+	}
+	require.NoError(t, json.Unmarshal(data, &header))
+	assert.Equal(t, "dpop+jwt", header.Typ)
+	assert.Equal(t, "ES256", header.Alg)
+
+	coord := func(name string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(header.JWK[name].(string))
+		require.NoError(t, err)
+		return new(big.Int).SetBytes(b)
+	}
+	pub := ecdsa.PublicKey{Curve: elliptic.P256(), X: coord("x"), Y: coord("y")}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.Len(t, sig, 64)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	require.True(t, ecdsa.Verify(&pub, digest[:], r, s), "invalid DPoP proof signature")
+
+	claims, err := jwt.Claims(proof)
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims["jti"])
+	assert.NotEmpty(t, claims["iat"])
+	return header.JWK, claims
+}
+
+func TestOAuthDPoP(t *testing.T) {
+	var fetches int
+	var tokenKey map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		key, claims := verifyProof(t, r.Header.Get("DPoP"))
+		assert.Equal(t, "POST", claims["htm"])
+		assert.Equal(t, "http://"+r.Host+"/token", claims["htu"])
+		assert.NotContains(t, claims, "ath")
+
+		// The server requires a nonce.
+		w.Header().Set("DPoP-Nonce", "token-nonce")
+		if claims["nonce"] != "token-nonce" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "use_dpop_nonce"}`))
+			return
+		}
+
+		fetches++
+		tokenKey = key
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "bound", "token_type": "DPoP", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	o, err := New(Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		TokenURL:     server.URL + "/token",
+		DPoP:         true,
+	}, nil)
+	require.NoError(t, err)
+
+	decorate := func() map[string]any {
+		req, err := http.NewRequest("GET", "https://api.example.com/things?page=2#top", nil)
+		require.NoError(t, err)
+		require.NoError(t, o.Decorate(req))
+		assert.Equal(t, "DPoP bound", req.Header.Get("Authorization"))
+
+		key, claims := verifyProof(t, req.Header.Get("DPoP"))
+		assert.Equal(t, tokenKey, key)
+		assert.Equal(t, "GET", claims["htm"])
+		assert.Equal(t, "https://api.example.com/things", claims["htu"])
+		sum := sha256.Sum256([]byte("bound"))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), claims["ath"])
+		return claims
+	}
+
+	claims := decorate()
+	assert.NotContains(t, claims, "nonce")
+	assert.Equal(t, 1, fetches)
+
+	_, err = o.Headers(context.Background())
+	assert.ErrorIs(t, err, errDPoPHeaders)
+
+	// The resource server asks for a nonce; the token is kept.
+	req, err := http.NewRequest("GET", "https://api.example.com/things", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "DPoP bound")
+	require.True(t, o.Challenge(req, &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{
+			"Www-Authenticate": {`DPoP error="use_dpop_nonce", algs="ES256"`},
+			"Dpop-Nonce":       {"resource-nonce"},
+		},
+	}))
+	claims = decorate()
+	assert.Equal(t, "resource-nonce", claims["nonce"])
+	assert.Equal(t, 1, fetches)
+
+	// The same nonce again means the request was rejected for another
+	// reason.
+	assert.False(t, o.Challenge(req, &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header: http.Header{
+			"Www-Authenticate": {`DPoP error="use_dpop_nonce"`},
+			"Dpop-Nonce":       {"resource-nonce"},
+		},
+	}))
+
+	// A rejected token is replaced.
+	require.True(t, o.Challenge(req, &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": {`DPoP error="invalid_token"`}},
+	}))
+	decorate()
+	assert.Equal(t, 2, fetches)
+}
+
+func TestOAuthDPoPObserve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "bound", "token_type": "DPoP", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	o, err := New(Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		TokenURL:     server.URL,
+		DPoP:         true,
+	}, nil)
+	require.NoError(t, err)
+
+	decorate := func(target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		require.NoError(t, o.Decorate(req))
+		return req
+	}
+	nonce := func(req *http.Request) any {
+		_, claims := verifyProof(t, req.Header.Get("DPoP"))
+		return claims["nonce"]
+	}
+
+	// A successful response carries the nonce for the next request.
+	req := decorate("https://api.example.com/things")
+	assert.Nil(t, nonce(req))
+	o.Observe(req, &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Dpop-Nonce": {"next-nonce"}},
+	})
+	assert.Equal(t, "next-nonce", nonce(decorate("https://api.example.com/other")))
+
+	// The nonce belongs to the server that sent it.
+	assert.Nil(t, nonce(decorate("https://other.example.com/things")))
+
+	// Responses to requests without a DPoP bound token are ignored.
+	req, err = http.NewRequest("GET", "https://api.example.com/things", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer token")
+	o.Observe(req, &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Dpop-Nonce": {"other-nonce"}},
+	})
+	assert.Equal(t, "next-nonce", nonce(decorate("https://api.example.com/things")))
+}
+
+func TestOAuthDPoPBearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get("DPoP"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "unbound", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	o, err := New(Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		TokenURL:     server.URL,
+		DPoP:         true,
+	}, nil)
+	require.NoError(t, err)
+
+	// The server did not bind the token, so it is used as a bearer token.
+	req, err := http.NewRequest("GET", "https://api.example.com/things", nil)
+	require.NoError(t, err)
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer unbound", req.Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("DPoP"))
+
+	h, err := o.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer unbound", h.Get("Authorization"))
+}
@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// The token types of RFC 8693 section 3.
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// tokenTypeNA is the token_type of issued tokens that are not access tokens.
+const tokenTypeNA = "N_A"
+
+// The defaults of Exchange.MaxEntries and Exchange.MaxAge.
+const (
+	defaultMaxEntries = 1024
+	defaultMaxAge     = time.Hour
+)
+
+var errNoSubjectToken = errors.New("no subject token in the context to exchange")
+
+type contextKey int
+
+const (
+	subjectTokenKey contextKey = iota
+	actorTokenKey
+)
+
+// WithSubjectToken returns a copy of the context carrying the token to
+// exchange for a token for the request, such as the token of the inbound
+// caller.
+func WithSubjectToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, subjectTokenKey, token)
+}
+
+// WithActorToken returns a copy of the context carrying the token of the
+// party acting on behalf of the subject.
+func WithActorToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, actorTokenKey, token)
+}
+
+// TokenProvider provides a token, such as the token of the workload's
+// Kubernetes service account.
+type TokenProvider interface {
+	Token(context.Context) (string, error)
+}
+
+// Exchange configures the token-exchange grant described in RFC 8693. The
+// subject and actor tokens are taken from the context of each request; see
+// WithSubjectToken and WithActorToken.
+type Exchange struct {
+	// SubjectTokenProvider provides the subject token when the context
+	// carries none, such as a serviceaccount.ServiceAccount.
+	SubjectTokenProvider TokenProvider
+
+	// SubjectTokenType is the type of the subject tokens. "" means default,
+	// which is "urn:ietf:params:oauth:token-type:access_token".
+	SubjectTokenType string
+
+	// ActorTokenType is the type of the actor tokens. "" means default,
+	// which is "urn:ietf:params:oauth:token-type:access_token".
+	ActorTokenType string
+
+	// Audience lists the logical names of the services the tokens are for.
+	Audience []string
+
+	// Resource lists the URIs of the services the tokens are for.
+	Resource []string
+
+	// RequestedTokenType is the optional type of the token to issue.
+	RequestedTokenType string
+
+	// MaxEntries is the number of subject and actor pairs whose exchanged
+	// tokens are cached; the least recently used pair is dropped first.
+	// 0 means default, which is 1024.
+	MaxEntries int
+
+	// MaxAge is the longest time the exchanged tokens of a pair are cached.
+	// They are never cached past the exp claim of a JWT subject or actor
+	// token. 0 means default, which is 1 hour.
+	MaxAge time.Duration
+}
+
+// exchanger caches the exchanged tokens of the most recently used subjects
+// and actors.
+type exchanger struct {
+	config    Exchange
+	base      func() *clientcredentials.Config
+	newFetch  func(func() *clientcredentials.Config) func(context.Context) (*oauth2.Token, error)
+	newSource func(func(context.Context) (*oauth2.Token, error)) *safetyMarginTokenSource
+	now       func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	sources map[[sha256.Size]byte]*list.Element
+}
+
+// exchange is a cache entry of an exchanger.
+type exchange struct {
+	key     [sha256.Size]byte
+	ts      *safetyMarginTokenSource
+	expires time.Time
+}
+
+func newExchanger(
+	config Exchange,
+	base func() *clientcredentials.Config,
+	newFetch func(func() *clientcredentials.Config) func(context.Context) (*oauth2.Token, error),
+	newSource func(func(context.Context) (*oauth2.Token, error)) *safetyMarginTokenSource,
+) *exchanger {
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = TokenTypeAccessToken
+	}
+	if config.ActorTokenType == "" {
+		config.ActorTokenType = TokenTypeAccessToken
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultMaxEntries
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = defaultMaxAge
+	}
+
+	return &exchanger{
+		config:    config,
+		base:      base,
+		newFetch:  newFetch,
+		newSource: newSource,
+		now:       time.Now,
+		lru:       list.New(),
+		sources:   map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+// tokens returns the subject and actor tokens of the context, and the key of
+// their exchanged token.
+func (e *exchanger) tokens(ctx context.Context) (string, string, [sha256.Size]byte, error) {
+	subject, _ := ctx.Value(subjectTokenKey).(string)
+	actor, _ := ctx.Value(actorTokenKey).(string)
+
+	if subject == "" && e.config.SubjectTokenProvider != nil {
+		var err error
+		subject, err = e.config.SubjectTokenProvider.Token(ctx)
+		if err != nil {
+			return "", "", [sha256.Size]byte{}, err
+		}
+	}
+	if subject == "" {
+		return "", "", [sha256.Size]byte{}, errNoSubjectToken
+	}
+
+	key := sha256.Sum256([]byte(subject + "\x00" + actor))
+	return subject, actor, key, nil
+}
+
+// source returns the token source for the subject of the context, creating
+// it if needed.
+func (e *exchanger) source(ctx context.Context) (*safetyMarginTokenSource, error) {
+	subject, actor, key, err := e.tokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ts := e.get(key, now); ts != nil {
+		return ts, nil
+	}
+
+	fetch := e.newFetch(e.request(subject, actor))
+	ts := e.newSource(func(ctx context.Context) (*oauth2.Token, error) {
+		tok, err := fetch(ctx)
+		// Tokens that are not access tokens have no type; they are still
+		// presented as bearer tokens.
+		if tok != nil && tok.TokenType == tokenTypeNA {
+			tok.TokenType = "Bearer"
+		}
+		return tok, err
+	})
+	e.sources[key] = e.lru.PushFront(&exchange{
+		key:     key,
+		ts:      ts,
+		expires: e.expires(now, subject, actor),
+	})
+	for e.lru.Len() > e.config.MaxEntries {
+		e.remove(e.lru.Back())
+	}
+	return ts, nil
+}
+
+// lookup returns the token source for the subject of the context, or nil if
+// there is none.
+func (e *exchanger) lookup(ctx context.Context) *safetyMarginTokenSource {
+	_, _, key, err := e.tokens(ctx)
+	if err != nil {
+		return nil
+	}
+
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.get(key, now)
+}
+
+// get returns the cached token source of a key and marks it as the most
+// recently used, or returns nil if there is none or it has expired. It must
+// be called with the lock held.
+func (e *exchanger) get(key [sha256.Size]byte, now time.Time) *safetyMarginTokenSource {
+	el := e.sources[key]
+	if el == nil {
+		return nil
+	}
+
+	x := el.Value.(*exchange)
+	if !now.Before(x.expires) {
+		e.remove(el)
+		return nil
+	}
+	e.lru.MoveToFront(el)
+	return x.ts
+}
+
+// remove drops a cache entry. It must be called with the lock held.
+func (e *exchanger) remove(el *list.Element) {
+	e.lru.Remove(el)
+	delete(e.sources, el.Value.(*exchange).key)
+}
+
+// expires returns when the cache entry of the tokens expires: after MaxAge,
+// or at the earliest exp claim of the tokens that are JWTs.
+func (e *exchanger) expires(now time.Time, tokens ...string) time.Time {
+	expires := now.Add(e.config.MaxAge)
+	for _, tok := range tokens {
+		if tok == "" {
+			continue
+		}
+		if exp, err := jwt.Expiry(tok); err == nil && exp.Before(expires) {
+			expires = exp
+		}
+	}
+	return expires
+}
+
+// request returns the token request configuration for the subject and actor
+// tokens.
+func (e *exchanger) request(subject, actor string) func() *clientcredentials.Config {
+	return func() *clientcredentials.Config {
+		cfg := e.base()
+
+		params := url.Values{}
+		for k, v := range cfg.EndpointParams {
+			params[k] = v
+		}
+		params.Set("grant_type", GrantTokenExchange)
+		params.Set("subject_token", subject)
+		params.Set("subject_token_type", e.config.SubjectTokenType)
+		if actor != "" {
+			params.Set("actor_token", actor)
+			params.Set("actor_token_type", e.config.ActorTokenType)
+		}
+		for _, a := range e.config.Audience {
+			params.Add("audience", a)
+		}
+		for _, r := range e.config.Resource {
+			params.Add("resource", r)
+		}
+		if e.config.RequestedTokenType != "" {
+			params.Set("requested_token_type", e.config.RequestedTokenType)
+		}
+
+		cfg.EndpointParams = params
+		return cfg
+	}
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+func TestOAuthExchange(t *testing.T) {
+	fetches := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, GrantTokenExchange, r.PostForm.Get("grant_type"))
+		assert.Equal(t, TokenTypeJWT, r.PostForm.Get("subject_token_type"))
+		assert.Equal(t, []string{"svc-a", "svc-b"}, r.PostForm["audience"])
+		assert.Equal(t, []string{"https://api.example.com"}, r.PostForm["resource"])
+		assert.Equal(t, TokenTypeAccessToken, r.PostForm.Get("requested_token_type"))
+		assert.Equal(t, "value", r.PostForm.Get("extra"))
+
+		subject := r.PostForm.Get("subject_token")
+		actor := r.PostForm.Get("actor_token")
+		if actor != "" {
+			assert.Equal(t, TokenTypeAccessToken, r.PostForm.Get("actor_token_type"))
+		} else {
+			assert.NotContains(t, r.PostForm, "actor_token_type")
+		}
+		fetches[subject+"/"+actor]++
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "%s-%s-%d", "issued_token_type": "%s", "token_type": "N_A", "expires_in": 3600}`,
+			subject, actor, fetches[subject+"/"+actor], TokenTypeAccessToken)
+	}))
+	defer server.Close()
+
+	var fetchEvents []events.FetchEvent
+	o, err := New(Config{
+		GrantType:      GrantTokenExchange,
+		TokenURL:       server.URL,
+		EndpointParams: map[string][]string{"extra": {"value"}},
+		Exchange: Exchange{
+			SubjectTokenType:   TokenTypeJWT,
+			Audience:           []string{"svc-a", "svc-b"},
+			Resource:           []string{"https://api.example.com"},
+			RequestedTokenType: TokenTypeAccessToken,
+		},
+	}, func(evnt any) {
+		if e, ok := evnt.(events.FetchEvent); ok {
+			fetchEvents = append(fetchEvents, e)
+		}
+	})
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	decorate := func(ctx context.Context) (*http.Request, error) {
+		req := httptest.NewRequestWithContext(ctx, "GET", "https://example.com", nil)
+		return req, o.Decorate(req)
+	}
+
+	// Without a subject token there is nothing to exchange.
+	_, err = decorate(context.Background())
+	assert.ErrorIs(t, err, errNoSubjectToken)
+
+	alice := WithSubjectToken(context.Background(), "alice")
+	bob := WithSubjectToken(context.Background(), "bob")
+	acting := WithActorToken(alice, "agent")
+
+	for range 2 {
+		req, err := decorate(alice)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer alice--1", req.Header.Get("Authorization"))
+	}
+
+	req, err := decorate(bob)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer bob--1", req.Header.Get("Authorization"))
+
+	req, err = decorate(acting)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer alice-agent-1", req.Header.Get("Authorization"))
+
+	assert.Equal(t, map[string]int{"alice/": 1, "bob/": 1, "alice/agent": 1}, fetches)
+	require.Len(t, fetchEvents, 3)
+	for _, e := range fetchEvents {
+		assert.NoError(t, e.Err)
+		assert.False(t, e.Expiration.IsZero())
+	}
+
+	// A rejected token only drops the token of its subject.
+	req, err = decorate(alice)
+	require.NoError(t, err)
+	resp := &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}},
+	}
+	assert.True(t, o.Challenge(req, resp))
+
+	req, err = decorate(alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer alice--2", req.Header.Get("Authorization"))
+
+	req, err = decorate(bob)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer bob--1", req.Header.Get("Authorization"))
+	assert.Equal(t, map[string]int{"alice/": 2, "bob/": 1, "alice/agent": 1}, fetches)
+}
+
+func TestExchangerCache(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	start := time.Now()
+	jwtExpiring := func(exp time.Time) string {
+		tok, err := jwt.Sign(key, nil, map[string]any{"sub": "alice", "exp": exp.Unix()})
+		require.NoError(t, err)
+		return tok
+	}
+	expiring := jwtExpiring(start.Add(time.Minute))
+	valid := jwtExpiring(start.Add(time.Hour))
+
+	tests := []struct {
+		description string
+		config      Exchange
+		subjects    []string
+		after       time.Duration
+		expectKept  []string
+	}{
+		{
+			description: "Least recently used entries are dropped",
+			config:      Exchange{MaxEntries: 2},
+			subjects:    []string{"a", "b", "a", "c"},
+			expectKept:  []string{"a", "c"},
+		},
+		{
+			description: "Entries expire after the maximum age",
+			config:      Exchange{MaxAge: time.Minute},
+			subjects:    []string{"a"},
+			after:       time.Minute,
+		},
+		{
+			description: "Entries are kept until the maximum age",
+			subjects:    []string{"a"},
+			after:       59 * time.Minute,
+			expectKept:  []string{"a"},
+		},
+		{
+			description: "Entries expire with their subject token",
+			subjects:    []string{expiring},
+			after:       time.Minute,
+		},
+		{
+			description: "Entries with an unexpired subject token are kept",
+			subjects:    []string{valid},
+			after:       time.Minute,
+			expectKept:  []string{valid},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			o, err := New(Config{
+				GrantType: GrantTokenExchange,
+				TokenURL:  "https://example.com/token",
+				Exchange:  tc.config,
+			}, nil)
+			require.NoError(t, err)
+
+			now := start
+			o.exchange.now = func() time.Time { return now }
+
+			created := map[string]*safetyMarginTokenSource{}
+			for _, subject := range tc.subjects {
+				ts, err := o.exchange.source(WithSubjectToken(context.Background(), subject))
+				require.NoError(t, err)
+				created[subject] = ts
+			}
+
+			now = now.Add(tc.after)
+			var kept []string
+			for _, subject := range tc.subjects {
+				ts := o.exchange.lookup(WithSubjectToken(context.Background(), subject))
+				if ts != nil && !slices.Contains(kept, subject) {
+					assert.Same(t, created[subject], ts)
+					kept = append(kept, subject)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectKept, kept)
+			assert.Len(t, o.exchange.sources, len(tc.expectKept))
+			assert.Equal(t, len(tc.expectKept), o.exchange.lru.Len())
+		})
+	}
+}
+
+type tokenProviderFunc func(context.Context) (string, error)
+
+func (f tokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func TestOAuthExchangeSubjectTokenProvider(t *testing.T) {
+	var subjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		subjects = append(subjects, r.PostForm.Get("subject_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "exchanged-%s", "token_type": "Bearer", "expires_in": 3600}`,
+			r.PostForm.Get("subject_token"))
+	}))
+	defer server.Close()
+
+	// The provider rotates its token, like a projected service account.
+	provided := "sa-1"
+	o, err := New(Config{
+		GrantType: GrantTokenExchange,
+		TokenURL:  server.URL,
+		Exchange: Exchange{
+			SubjectTokenType: TokenTypeJWT,
+			SubjectTokenProvider: tokenProviderFunc(func(context.Context) (string, error) {
+				return provided, nil
+			}),
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	decorate := func(ctx context.Context) string {
+		req := httptest.NewRequestWithContext(ctx, "GET", "https://example.com", nil)
+		require.NoError(t, o.Decorate(req))
+		return req.Header.Get("Authorization")
+	}
+
+	assert.Equal(t, "Bearer exchanged-sa-1", decorate(context.Background()))
+	assert.Equal(t, "Bearer exchanged-sa-1", decorate(context.Background()))
+
+	provided = "sa-2"
+	assert.Equal(t, "Bearer exchanged-sa-2", decorate(context.Background()))
+
+	// A subject token in the context wins.
+	assert.Equal(t, "Bearer exchanged-user", decorate(WithSubjectToken(context.Background(), "user")))
+	assert.Equal(t, []string{"sa-1", "sa-2", "user"}, subjects)
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"golang.org/x/oauth2"
+)
+
+// callbackPath is the path of the loopback redirect URI.
+const callbackPath = "/callback"
+
+var (
+	errNoAuthURL     = errors.New("AuthURL and TokenURL are required for a loopback login")
+	errStateMismatch = errors.New("the authorization response state does not match the request")
+	errNoProfile     = errors.New("invalid profile name")
+)
+
+// ProfileFile returns the path of the file caching the tokens of the named
+// profile in the user's cache directory. The directory is created if
+// needed.
+func ProfileFile(profile string) (string, error) {
+	if profile == "" || profile != filepath.Base(profile) || strings.HasPrefix(profile, ".") {
+		return "", fmt.Errorf("%w: %q", errNoProfile, profile)
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "vouch")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profile+".json"), nil
+}
+
+// LoopbackLogin runs the authorization code grant with PKCE, receiving the
+// authorization response on a loopback redirect as described in RFC 8252.
+// It uses the AuthURL, TokenURL, ClientID, ClientSecret, AuthStyle and
+// Scopes of the configuration.
+//
+// A listener is started on 127.0.0.1 and open is called with the URL the
+// user must visit, such as by opening it in a browser. The code is exchanged
+// for the tokens once the user is redirected back, unless the context is
+// canceled first. Requests to the listener without the state of the
+// authorization request are rejected and do not end the login.
+//
+// The tokens can be saved to a FileStore and reused with the refresh_token
+// grant.
+func LoopbackLogin(ctx context.Context, config Config, open func(authURL string)) (TokenSet, error) {
+	if config.AuthURL == "" || config.TokenURL == "" {
+		return TokenSet{}, errNoAuthURL
+	}
+
+	style, ok := styleMap[config.AuthStyle]
+	if !ok {
+		return TokenSet{}, fmt.Errorf("invalid AuthStyle: %s", config.AuthStyle)
+	}
+	if config.ClientSecret == "" && style == oauth2.AuthStyleAutoDetect {
+		style = oauth2.AuthStyleInParams
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return TokenSet{}, err
+	}
+	defer l.Close()
+
+	oc := oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Scopes:       config.Scopes,
+		RedirectURL:  "http://" + l.Addr().String() + callbackPath,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   config.AuthURL,
+			TokenURL:  config.TokenURL,
+			AuthStyle: style,
+		},
+	}
+
+	state := jwt.ID()
+	verifier := oauth2.GenerateVerifier()
+
+	results := make(chan callbackResult, 1)
+	srv := http.Server{
+		Handler: callbackHandler(state, results),
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	open(oc.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)))
+
+	return exchangeCode(ctx, &oc, results, verifier)
+}
+
+// exchangeCode waits for the authorization response and exchanges its code
+// for the tokens.
+func exchangeCode(ctx context.Context, oc *oauth2.Config, results <-chan callbackResult, verifier string) (TokenSet, error) {
+	var res callbackResult
+	select {
+	case <-ctx.Done():
+		return TokenSet{}, ctx.Err()
+	case res = <-results:
+	}
+	if res.err != nil {
+		return TokenSet{}, res.err
+	}
+
+	tok, err := oc.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return TokenSet{}, err
+	}
+	return tokenSet(tok), nil
+}
+
+// callbackResult is the outcome of the authorization response.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// callbackHandler receives the authorization response on the loopback
+// redirect and sends its outcome to results. Requests without the state of
+// the login are answered with 400 and otherwise ignored, so that other local
+// clients cannot end the login.
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != callbackPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(w, errStateMismatch.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var res callbackResult
+		switch {
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			res.err = errors.New("the authorization response has no code")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login complete, you may close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopbackLogin(t *testing.T) {
+	tests := []struct {
+		description string
+		response    func(authURL *url.URL) url.Values
+		expectError string
+	}{
+		{
+			description: "Approved",
+			response: func(authURL *url.URL) url.Values {
+				return url.Values{"code": {"code"}, "state": {authURL.Query().Get("state")}}
+			},
+		},
+		{
+			description: "Denied",
+			response: func(authURL *url.URL) url.Values {
+				return url.Values{"error": {"access_denied"}, "state": {authURL.Query().Get("state")}}
+			},
+			expectError: "access_denied",
+		},
+		{
+			description: "No code",
+			response: func(authURL *url.URL) url.Values {
+				return url.Values{"state": {authURL.Query().Get("state")}}
+			},
+			expectError: "no code",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var challenge string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+				assert.Equal(t, "code", r.PostForm.Get("code"))
+				assert.Equal(t, "cli", r.PostForm.Get("client_id"))
+
+				sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+				assert.Equal(t, challenge, base64.RawURLEncoding.EncodeToString(sum[:]))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token": "access", "token_type": "Bearer", "refresh_token": "refresh", "expires_in": 3600}`))
+			}))
+			defer server.Close()
+
+			var callback *http.Response
+			set, err := LoopbackLogin(context.Background(), Config{
+				ClientID: "cli",
+				AuthURL:  "https://idp.example.com/authorize",
+				TokenURL: server.URL,
+				Scopes:   []string{"openid"},
+			}, func(authURL string) {
+				u, err := url.Parse(authURL)
+				require.NoError(t, err)
+				q := u.Query()
+				assert.Equal(t, "idp.example.com", u.Host)
+				assert.Equal(t, "code", q.Get("response_type"))
+				assert.Equal(t, "cli", q.Get("client_id"))
+				assert.Equal(t, "openid", q.Get("scope"))
+				assert.Equal(t, "S256", q.Get("code_challenge_method"))
+				assert.NotEmpty(t, q.Get("state"))
+				challenge = q.Get("code_challenge")
+
+				redirect, err := url.Parse(q.Get("redirect_uri"))
+				require.NoError(t, err)
+				assert.Equal(t, "127.0.0.1", redirect.Hostname())
+				redirect.RawQuery = tc.response(u).Encode()
+
+				// The browser follows the redirect back to the listener.
+				callback, err = http.Get(redirect.String())
+				require.NoError(t, err)
+			})
+
+			require.NotNil(t, callback)
+			body, _ := io.ReadAll(callback.Body)
+			callback.Body.Close()
+
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				assert.Equal(t, http.StatusBadRequest, callback.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, callback.StatusCode)
+			assert.Contains(t, string(body), "Login complete")
+			assert.Equal(t, "access", set.AccessToken)
+			assert.Equal(t, "refresh", set.RefreshToken)
+		})
+	}
+}
+
+func TestLoopbackLoginForgedState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "code", r.PostForm.Get("code"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "access", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	set, err := LoopbackLogin(context.Background(), Config{
+		ClientID: "cli",
+		AuthURL:  "https://idp.example.com/authorize",
+		TokenURL: server.URL,
+	}, func(authURL string) {
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		redirect, err := url.Parse(u.Query().Get("redirect_uri"))
+		require.NoError(t, err)
+
+		// Callbacks from other local clients are rejected without ending
+		// the login.
+		for _, forged := range []url.Values{
+			{"code": {"forged"}, "state": {"forged"}},
+			{"error": {"access_denied"}, "state": {"forged"}},
+			{"code": {"forged"}},
+		} {
+			redirect.RawQuery = forged.Encode()
+			resp, err := http.Get(redirect.String())
+			require.NoError(t, err)
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Contains(t, string(body), errStateMismatch.Error())
+		}
+
+		redirect.RawQuery = url.Values{"code": {"code"}, "state": {u.Query().Get("state")}}.Encode()
+		resp, err := http.Get(redirect.String())
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", set.AccessToken)
+}
+
+func TestLoopbackLoginCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := LoopbackLogin(ctx, Config{
+		AuthURL:  "https://idp.example.com/authorize",
+		TokenURL: "https://idp.example.com/token",
+	}, func(string) {
+		cancel()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = LoopbackLogin(context.Background(), Config{TokenURL: "https://idp.example.com/token"}, func(string) {})
+	assert.ErrorIs(t, err, errNoAuthURL)
+}
+
+func TestProfileFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	t.Setenv("LocalAppData", filepath.Join(home, "cache"))
+
+	for _, profile := range []string{"", ".", "..", "../escape", "a/b", ".hidden"} {
+		_, err := ProfileFile(profile)
+		assert.ErrorIs(t, err, errNoProfile, profile)
+	}
+
+	file, err := ProfileFile("work")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(file, home), file)
+	assert.Equal(t, "work.json", filepath.Base(file))
+
+	info, err := os.Stat(filepath.Dir(file))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	// A login saved to the profile is reused by the refresh_token grant.
+	require.NoError(t, FileStore(file).Write(TokenSet{
+		AccessToken:  "access",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	}))
+
+	o, err := New(Config{
+		GrantType: GrantRefreshToken,
+		TokenURL:  "https://idp.example.com/token",
+		Profile:   "work",
+	}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "https://example.com", nil)
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer access", req.Header.Get("Authorization"))
+
+	_, err = New(Config{
+		GrantType:        GrantRefreshToken,
+		TokenURL:         "https://idp.example.com/token",
+		Profile:          "work",
+		RefreshTokenFile: file,
+	}, nil)
+	assert.Error(t, err)
+}
@@ -5,9 +5,12 @@ package oauth
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,12 +23,55 @@ const (
 	OAUTH2_TYPE = "oauth2"
 )
 
+// The grant types that may be used to request tokens.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	GrantTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	GrantRefreshToken      = "refresh_token"
+
+	// GrantThemis is not an OAuth grant; the raw JWT is fetched with an
+	// authenticated GET of the TokenURL, as Themis issuers expect.
+	GrantThemis = "themis"
+)
+
 type Config struct {
 	// Priority is the priority of this config relative to others.
 	// Higher numbers are higher priority. 0 means default, which is 1000.
 	Priority int
 
-	// ClientID is the application's ID.
+	// GrantType selects how tokens are requested.
+	// Valid values: ""/"client_credentials",
+	// "urn:ietf:params:oauth:grant-type:jwt-bearer",
+	// "urn:ietf:params:oauth:grant-type:token-exchange", "refresh_token",
+	// "themis"
+	GrantType string
+
+	// RefreshToken is the initial refresh token of the refresh_token grant.
+	// It is ignored once the token store holds a rotated one.
+	RefreshToken string
+
+	// RefreshTokenFile is the path of the file that persists the refresh
+	// tokens rotated by the server.
+	RefreshTokenFile string
+
+	// RefreshTokenStore persists the refresh tokens rotated by the server.
+	// It may be set instead of RefreshTokenFile.
+	RefreshTokenStore TokenStore
+
+	// Profile names the tokens cached by a login with the CLI; see
+	// ProfileFile. It may be set instead of RefreshTokenFile.
+	Profile string
+
+	// Exchange configures the token-exchange grant.
+	Exchange Exchange
+
+	// Assertion configures the signed JWT sent with the jwt-bearer grant
+	// and the client assertion of the private_key_jwt AuthStyle.
+	Assertion Assertion
+
+	// ClientID is the application's ID. It is optional for the jwt-bearer,
+	// token-exchange, refresh_token and themis grants.
 	ClientID string
 
 	// ClientSecret is the application's secret.
@@ -34,17 +80,43 @@ type Config struct {
 	// TokenURL is the resource server's token endpoint URL.
 	TokenURL string
 
+	// DeviceAuthURL is the device authorization endpoint URL used by
+	// DeviceLogin.
+	DeviceAuthURL string
+
+	// AuthURL is the authorization endpoint URL used by LoopbackLogin.
+	AuthURL string
+
 	// Scopes specifies optional requested permissions.
 	Scopes []string
 
 	// EndpointParams specifies additional parameters for requests to the token endpoint.
 	EndpointParams url.Values
 
+	// TokenHeaders are sent with the requests of the themis grant, such as
+	// an API key. The ClientID and ClientSecret are sent as basic
+	// credentials when there is a ClientSecret.
+	TokenHeaders http.Header
+
 	// AuthStyle optionally specifies how the endpoint wants the
-	// client ID & client secret sent.
-	// Valid values: ""/"auto_detect", "in_params", "in_header"
+	// client ID & client secret sent. With "private_key_jwt" the client
+	// authenticates with a JWT signed by the Assertion key instead of a
+	// secret, and with "tls_client_auth" it authenticates with the TLS
+	// client certificate.
+	// Valid values: ""/"auto_detect", "in_params", "in_header",
+	// "private_key_jwt", "tls_client_auth"
 	AuthStyle string
 
+	// DPoP binds the tokens to a key pair generated by New. A DPoP proof is
+	// sent with each token request and with each decorated request, as
+	// described in RFC 9449.
+	DPoP bool
+
+	// TLS configures mutual TLS with the token endpoint. See OAuth.TLSConfig
+	// to present the same certificate to resource servers, as required by
+	// certificate-bound access tokens.
+	TLS TLS
+
 	// ExpirationSafetyMargin is the percentage of the token lifetime
 	// to use as a safety margin for token refresh. (0.8 = 20% early refresh)
 	ExpirationSafetyMargin float64
@@ -55,11 +127,18 @@ type Config struct {
 }
 
 func (c *Config) IsActive() bool {
+	switch c.GrantType {
+	case GrantJWTBearer, GrantTokenExchange, GrantRefreshToken, GrantThemis:
+		return c.TokenURL != ""
+	}
 	return c.ClientID != "" && c.TokenURL != ""
 }
 
 type OAuth struct {
-	ts       oauth2.TokenSource
+	ts       *safetyMarginTokenSource
+	exchange *exchanger
+	tls      *clientTLS
+	dpop     *dpop
 	dispatch func(any)
 	priority int
 }
@@ -68,22 +147,35 @@ func (o *OAuth) Priority() int {
 	return o.priority
 }
 
+// privateKeyJWT is the AuthStyle that authenticates the client with a signed
+// JWT rather than a secret.
+const privateKeyJWT = "private_key_jwt"
+
 var styleMap = map[string]oauth2.AuthStyle{
 	"":            oauth2.AuthStyleAutoDetect,
 	"auto_detect": oauth2.AuthStyleAutoDetect,
 	"in_params":   oauth2.AuthStyleInParams,
 	"in_header":   oauth2.AuthStyleInHeader,
+
+	// Only the client ID is sent in the parameters.
+	privateKeyJWT: oauth2.AuthStyleInParams,
+	tlsClientAuth: oauth2.AuthStyleInParams,
 }
 
 // New creates an OAuth client that automatically refreshes tokens safely.
+// If the configuration is not active, New returns nil after validating it.
 func New(config Config, dispatch func(any)) (*OAuth, error) {
-	style, ok := styleMap[config.AuthStyle]
-	if !ok {
-		return nil, fmt.Errorf("invalid AuthStyle: %s", config.AuthStyle)
+	style, err := validate(config)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.ExpirationSafetyMargin < 0 || config.ExpirationSafetyMargin > 1 {
-		return nil, fmt.Errorf("ExpirationSafetyMargin must be between 0 and 1")
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	if err := validateClient(config); err != nil {
+		return nil, err
 	}
 
 	priority := config.Priority
@@ -91,81 +183,481 @@ func New(config Config, dispatch func(any)) (*OAuth, error) {
 		priority = 1000
 	}
 
-	cfg := clientcredentials.Config{
-		ClientID:       config.ClientID,
-		ClientSecret:   config.ClientSecret,
-		TokenURL:       config.TokenURL,
-		Scopes:         config.Scopes,
-		EndpointParams: config.EndpointParams,
-		AuthStyle:      style,
+	// A client without a secret must not send an empty one in the header.
+	if config.ClientSecret == "" && style == oauth2.AuthStyleAutoDetect {
+		style = oauth2.AuthStyleInParams
 	}
 
-	baseTS := cfg.TokenSource(context.Background())
+	f, err := newFetcher(config, style)
+	if err != nil {
+		return nil, err
+	}
 
 	if dispatch == nil {
 		dispatch = func(any) {}
 	}
 
-	// Wrap base source to inject synthetic expiry if needed
-	safeTS := &safetyMarginTokenSource{
-		source:               baseTS,
-		safetyMargin:         config.ExpirationSafetyMargin,
-		defaultTokenLifetime: config.DefaultTokenDuration,
-		dispatch:             dispatch,
+	newSource := sources(config, dispatch)
+	o := OAuth{
+		tls:      f.tls,
+		dpop:     f.dpop,
+		dispatch: dispatch,
+		priority: priority,
 	}
 
-	// Wrap again with ReuseTokenSource for caching and refresh
-	reuseTS := oauth2.ReuseTokenSource(nil, safeTS)
+	switch config.GrantType {
+	case GrantTokenExchange:
+		o.exchange = newExchanger(config.Exchange, f.base, f.fetch, newSource)
+	case GrantThemis:
+		o.ts = newSource(f.withClient(themisFetch(config)))
+	case GrantRefreshToken:
+		r, err := newRefresherFor(config)
+		if err != nil {
+			return nil, err
+		}
+		o.ts = newSource(r.fetch(f.base, f.fetch))
+		o.ts.lastToken = r.cached
+		o.ts.rotates = true
+	default:
+		o.ts = newSource(f.fetch(f.base))
+	}
 
-	return &OAuth{
-		ts:       reuseTS,
-		dispatch: dispatch,
-		priority: priority,
-	}, nil
+	return &o, nil
 }
 
-// Decorate sets the Authorization header on an outgoing request.
+// sources returns a function that caches the tokens of a fetch with the
+// safety margin of the configuration.
+func sources(config Config, dispatch func(any)) func(func(context.Context) (*oauth2.Token, error)) *safetyMarginTokenSource {
+	return func(fetch func(context.Context) (*oauth2.Token, error)) *safetyMarginTokenSource {
+		return &safetyMarginTokenSource{
+			fetch:                fetch,
+			safetyMargin:         config.ExpirationSafetyMargin,
+			defaultTokenLifetime: config.DefaultTokenDuration,
+			dispatch:             dispatch,
+		}
+	}
+}
+
+// validate checks the configuration, whether or not it is active, and
+// returns its AuthStyle.
+func validate(config Config) (oauth2.AuthStyle, error) {
+	style, ok := styleMap[config.AuthStyle]
+	if !ok {
+		return style, fmt.Errorf("invalid AuthStyle: %s", config.AuthStyle)
+	}
+
+	switch config.GrantType {
+	case "", GrantClientCredentials, GrantJWTBearer, GrantTokenExchange, GrantRefreshToken, GrantThemis:
+	default:
+		return style, fmt.Errorf("invalid GrantType: %s", config.GrantType)
+	}
+
+	if config.ExpirationSafetyMargin < 0 || config.ExpirationSafetyMargin > 1 {
+		return style, fmt.Errorf("ExpirationSafetyMargin must be between 0 and 1")
+	}
+	return style, nil
+}
+
+// validateClient checks how an active configuration authenticates the
+// client.
+func validateClient(config Config) error {
+	switch config.AuthStyle {
+	case privateKeyJWT, tlsClientAuth:
+		if config.ClientID == "" {
+			return fmt.Errorf("ClientID is required with the %s AuthStyle", config.AuthStyle)
+		}
+		if config.ClientSecret != "" {
+			return fmt.Errorf("ClientSecret must not be set with the %s AuthStyle", config.AuthStyle)
+		}
+	}
+
+	if config.AuthStyle == tlsClientAuth && config.TLS.CertFile == "" {
+		return fmt.Errorf("TLS CertFile is required with the %s AuthStyle", tlsClientAuth)
+	}
+
+	if config.GrantType == GrantThemis && (config.DPoP || config.AuthStyle == privateKeyJWT) {
+		return fmt.Errorf("DPoP and the %s AuthStyle are not supported with the %s grant", privateKeyJWT, GrantThemis)
+	}
+	return nil
+}
+
+// newRefresherFor creates the refresher of the refresh_token grant, using
+// the store of the configuration.
+func newRefresherFor(config Config) (*refresher, error) {
+	file := config.RefreshTokenFile
+	if config.Profile != "" {
+		if file != "" {
+			return nil, errors.New("only one of RefreshTokenFile and Profile may be set")
+		}
+		var err error
+		if file, err = ProfileFile(config.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	store := config.RefreshTokenStore
+	if file != "" {
+		if store != nil {
+			return nil, errors.New("RefreshTokenStore must not be set with RefreshTokenFile or Profile")
+		}
+		store = FileStore(file)
+	}
+
+	return newRefresher(config.RefreshToken, store)
+}
+
+// fetcher requests tokens from the token endpoint, signing assertions,
+// sending DPoP proofs and presenting a TLS client certificate as
+// configured.
+type fetcher struct {
+	base       func() *clientcredentials.Config
+	grant      *assertion
+	client     *assertion
+	dpop       *dpop
+	tls        *clientTLS
+	httpClient *http.Client
+}
+
+func newFetcher(config Config, style oauth2.AuthStyle) (*fetcher, error) {
+	f := fetcher{
+		base: func() *clientcredentials.Config {
+			return &clientcredentials.Config{
+				ClientID:       config.ClientID,
+				ClientSecret:   config.ClientSecret,
+				TokenURL:       config.TokenURL,
+				Scopes:         config.Scopes,
+				EndpointParams: config.EndpointParams,
+				AuthStyle:      style,
+			}
+		},
+	}
+
+	if config.GrantType == GrantJWTBearer || config.AuthStyle == privateKeyJWT {
+		a, err := newAssertion(config.Assertion, config.ClientID, config.TokenURL)
+		if err != nil {
+			return nil, err
+		}
+		if config.GrantType == GrantJWTBearer {
+			f.grant = a
+		}
+		if config.AuthStyle == privateKeyJWT {
+			f.client = a.forClient(config.ClientID)
+		}
+	}
+
+	if config.DPoP {
+		var err error
+		if f.dpop, err = newDPoP(); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.TLS.isActive() {
+		var err error
+		if f.tls, err = newClientTLS(config.TLS); err != nil {
+			return nil, err
+		}
+		f.httpClient = f.tls.client()
+	}
+
+	return &f, nil
+}
+
+// fetch returns a function that requests a new token with the request
+// configuration. Every fetch requests a new token; caching is done by the
+// safety margin source so a rejected token can be dropped.
+func (f *fetcher) fetch(base func() *clientcredentials.Config) func(context.Context) (*oauth2.Token, error) {
+	fetch := base().Token
+	if f.grant != nil || f.client != nil {
+		fetch = signedFetch(base, f.grant, f.client)
+	}
+	if f.dpop != nil {
+		fetch = withDPoP(fetch, f.dpop)
+	}
+	return f.withClient(fetch)
+}
+
+// withClient makes the fetch present the TLS client certificate, if any.
+func (f *fetcher) withClient(fetch func(context.Context) (*oauth2.Token, error)) func(context.Context) (*oauth2.Token, error) {
+	if f.httpClient == nil {
+		return fetch
+	}
+	return withClient(fetch, f.httpClient)
+}
+
+// TLSConfig returns a TLS configuration that presents the same client
+// certificate as the token requests and trusts the same CA certificates, or
+// nil if TLS is not configured. The client certificate is read again when
+// it changes; the CA certificates are those current at the time of the
+// call. Resource servers that accept certificate-bound access tokens must
+// be called with it:
+//
+//	transport := http.DefaultTransport.(*http.Transport).Clone()
+//	transport.TLSClientConfig = o.TLSConfig()
+func (o *OAuth) TLSConfig() *tls.Config {
+	if o.tls == nil {
+		return nil
+	}
+	return o.tls.config()
+}
+
+// Decorate sets the Authorization header on an outgoing request. The
+// request's context bounds how long Decorate waits for a token to be fetched.
 func (o *OAuth) Decorate(req *http.Request) error {
+	h, err := o.headers(req.Context(), req)
+	if err != nil {
+		return err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	return nil
+}
+
+// Headers returns the headers that Decorate sets on a request, for protocols
+// that do not send an *http.Request. The context bounds how long Headers
+// waits for a token to be fetched. DPoP bound tokens cannot be provided as
+// headers.
+func (o *OAuth) Headers(ctx context.Context) (http.Header, error) {
+	return o.headers(ctx, nil)
+}
+
+// headers returns the headers for the request, which is nil when the headers
+// are not for a request.
+func (o *OAuth) headers(ctx context.Context, req *http.Request) (http.Header, error) {
 	evnt := events.DecorateEvent{
 		At:   time.Now(),
 		Type: OAUTH2_TYPE,
 	}
-	token, err := o.ts.Token()
+	h, token, err := o.authorize(ctx, req)
 	evnt.Duration = time.Since(evnt.At)
 	if err != nil {
 		evnt.Err = err
 		o.dispatch(evnt)
-		return err
+		return nil, err
 	}
 	evnt.Expiration = token.Expiry
 
-	token.SetAuthHeader(req)
-
 	o.dispatch(evnt)
-	return nil
+	return h, nil
+}
+
+func (o *OAuth) authorize(ctx context.Context, req *http.Request) (http.Header, *oauth2.Token, error) {
+	ts, err := o.source(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := ts.token(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A token the server did not bind to the key is a plain bearer token.
+	if o.dpop == nil || (token.TokenType != "" && strings.EqualFold(token.TokenType, "Bearer")) {
+		return http.Header{
+			"Authorization": {token.Type() + " " + token.AccessToken},
+		}, token, nil
+	}
+
+	if req == nil {
+		return nil, nil, errDPoPHeaders
+	}
+	proof, err := o.dpop.proof(req.Method, req.URL, token.AccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := http.Header{}
+	h.Set("Authorization", "DPoP "+token.AccessToken)
+	h.Set(headerDPoP, proof)
+	return h, token, nil
+}
+
+// Challenge handles a 401 response to a request decorated by this OAuth.
+// If the server issued a challenge for the scheme of the token, the token
+// sent with the request is dropped from the cache so the next decoration
+// fetches a new one. A DPoP challenge that only asks for a new nonce keeps
+// the token. It returns true if the request should be retried.
+func (o *OAuth) Challenge(req *http.Request, resp *http.Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	scheme, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if token == "" || !hasChallenge(resp.Header, scheme) {
+		return false
+	}
+
+	if o.dpop != nil && strings.EqualFold(scheme, "DPoP") {
+		// A nonce that was already sent cannot help.
+		fresh := o.dpop.updateNonce(req.URL, resp)
+		if needsNonce(resp.Header) {
+			return fresh
+		}
+	} else if !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+
+	if ts := o.lookup(req.Context()); ts != nil {
+		ts.invalidate(token)
+	}
+	return true
+}
+
+// Observe keeps the DPoP nonce a server sends with a response to a request
+// decorated with a DPoP bound token, so the next proof for the server carries
+// it. The nonce of a 401 response that is retried is kept by Challenge.
+func (o *OAuth) Observe(req *http.Request, resp *http.Response) {
+	if o.dpop == nil || resp == nil {
+		return
+	}
+
+	scheme, _, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if strings.EqualFold(scheme, "DPoP") {
+		o.dpop.updateNonce(req.URL, resp)
+	}
+}
+
+// hasChallenge reports whether the WWW-Authenticate headers contain a
+// challenge for the given authentication scheme.
+func hasChallenge(h http.Header, scheme string) bool {
+	for _, v := range h.Values("WWW-Authenticate") {
+		for _, part := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(part), " ")
+			if strings.EqualFold(name, scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// source returns the token source for the context.
+func (o *OAuth) source(ctx context.Context) (*safetyMarginTokenSource, error) {
+	if o.exchange != nil {
+		return o.exchange.source(ctx)
+	}
+	return o.ts, nil
+}
+
+// lookup returns the token source for the context if there is one.
+func (o *OAuth) lookup(ctx context.Context) *safetyMarginTokenSource {
+	if o.exchange != nil {
+		return o.exchange.lookup(ctx)
+	}
+	return o.ts
 }
 
-// safetyMarginTokenSource ensures token has a safe expiry with a margin.
+// needsNonce reports whether a DPoP challenge asks for a new nonce.
+func needsNonce(h http.Header) bool {
+	for _, v := range h.Values("WWW-Authenticate") {
+		if strings.Contains(v, errUseDPoPNonce) {
+			return true
+		}
+	}
+	return false
+}
+
+// safetyMarginTokenSource ensures token has a safe expiry with a margin and
+// caches it until then.
+//
+// Concurrent callers share a single fetch. Each caller only waits for as long
+// as its own context allows; the fetch itself is canceled once every caller
+// waiting for it has given up.
 type safetyMarginTokenSource struct {
-	source               oauth2.TokenSource
+	fetch                func(context.Context) (*oauth2.Token, error)
 	safetyMargin         float64
 	defaultTokenLifetime time.Duration
 	dispatch             func(any)
 
-	mu           sync.Mutex
-	lastToken    *oauth2.Token
-	lastAdjusted bool
+	// rotates is set when the fetch redeems a refresh token, returning a
+	// RefreshToken only when the server rotated it; see refresher.fetch.
+	rotates bool
+
+	mu        sync.Mutex
+	lastToken *oauth2.Token
+	forced    bool
+	inflight  *tokenFetch
+}
+
+// tokenFetch is a token request shared by the callers waiting for it.
+type tokenFetch struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	token   *oauth2.Token
+	err     error
 }
 
-func (s *safetyMarginTokenSource) Token() (*oauth2.Token, error) {
+func (s *safetyMarginTokenSource) token(ctx context.Context) (*oauth2.Token, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	if s.lastToken.Valid() {
+		tok := s.lastToken
+		s.mu.Unlock()
+		return tok, nil
+	}
+
+	f := s.inflight
+	if f == nil {
+		// The fetch keeps the values of the context that started it, but not
+		// its deadline or cancellation.
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &tokenFetch{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		s.inflight = f
+		go s.run(fctx, f, s.forced)
+	}
+	f.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	f.waiters--
+	if f.waiters == 0 {
+		f.cancel()
+		if s.inflight == f {
+			s.inflight = nil
+		}
+	}
+	s.mu.Unlock()
 
+	return nil, ctx.Err()
+}
+
+// run performs the fetch and publishes the result to its waiters.
+func (s *safetyMarginTokenSource) run(ctx context.Context, f *tokenFetch, forced bool) {
+	defer f.cancel()
+
+	tok, err := s.fetchToken(ctx, forced)
+
+	s.mu.Lock()
+	if err == nil {
+		s.lastToken = tok
+		s.forced = false
+	}
+	if s.inflight == f {
+		s.inflight = nil
+	}
+	f.token, f.err = tok, err
+	s.mu.Unlock()
+
+	close(f.done)
+}
+
+func (s *safetyMarginTokenSource) fetchToken(ctx context.Context, forced bool) (*oauth2.Token, error) {
 	evnt := events.FetchEvent{
-		At: time.Now(),
+		At:     time.Now(),
+		Forced: forced,
 	}
 
-	tok, err := s.source.Token()
+	tok, err := s.fetch(ctx)
 	if err != nil {
 		evnt.Err = err
 		s.dispatch(evnt)
@@ -173,13 +665,6 @@ func (s *safetyMarginTokenSource) Token() (*oauth2.Token, error) {
 	}
 	evnt.Duration = time.Since(evnt.At)
 
-	// If this is the same token we already adjusted, skip re-adjustment
-	if tok == s.lastToken && s.lastAdjusted {
-		evnt.Expiration = tok.Expiry
-		s.dispatch(evnt)
-		return tok, nil
-	}
-
 	issuedAt := time.Now()
 
 	var lifetime time.Duration
@@ -196,10 +681,21 @@ func (s *safetyMarginTokenSource) Token() (*oauth2.Token, error) {
 		tok.Expiry = newExpiry
 	}
 
-	s.lastToken = tok
-	s.lastAdjusted = true
-
 	evnt.Expiration = tok.Expiry
+	evnt.Rotated = s.rotates && tok.RefreshToken != ""
 	s.dispatch(evnt)
 	return tok, nil
 }
+
+// invalidate drops the cached token if it is the given access token, forcing
+// the next call to token to fetch a new one. A token that has already been
+// replaced is left alone so concurrent rejections only cause one fetch.
+func (s *safetyMarginTokenSource) invalidate(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastToken != nil && s.lastToken.AccessToken == accessToken {
+		s.lastToken = nil
+		s.forced = true
+	}
+}
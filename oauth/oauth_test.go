@@ -4,12 +4,17 @@
 package oauth
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
 )
 
 func TestNew(t *testing.T) {
@@ -17,8 +22,14 @@ func TestNew(t *testing.T) {
 		description string
 		config      Config
 		dispatch    func(any)
+		expectNil   bool
 		expectError bool
 	}{
+		{
+			description: "Inactive configuration",
+			config:      Config{},
+			expectNil:   true,
+		},
 		{
 			description: "Valid configuration",
 			config: Config{
@@ -50,15 +61,43 @@ func TestNew(t *testing.T) {
 			dispatch:    func(any) {},
 			expectError: true,
 		},
+		{
+			description: "Refresh token grant",
+			config: Config{
+				GrantType:    GrantRefreshToken,
+				TokenURL:     "https://example.com/token",
+				RefreshToken: "refresh",
+			},
+		},
+		{
+			description: "Refresh token grant without a refresh token",
+			config: Config{
+				GrantType: GrantRefreshToken,
+				TokenURL:  "https://example.com/token",
+			},
+			expectError: true,
+		},
+		{
+			description: "Refresh token file and store",
+			config: Config{
+				GrantType:         GrantRefreshToken,
+				TokenURL:          "https://example.com/token",
+				RefreshToken:      "refresh",
+				RefreshTokenFile:  "refresh.txt",
+				RefreshTokenStore: FileStore("refresh.txt"),
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
-			_, err := New(tc.config, tc.dispatch)
+			got, err := New(tc.config, tc.dispatch)
 			if tc.expectError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tc.expectNil, got == nil)
 			}
 		})
 	}
@@ -135,6 +174,186 @@ func TestOAuthEndToEnd(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+
+			h, err := got.Headers(context.Background())
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, h)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "Bearer mock-token", h.Get("Authorization"))
+				assert.Equal(t, h.Get("Authorization"), req.Header.Get("Authorization"))
+			}
 		})
 	}
 }
+
+func TestOAuthChallenge(t *testing.T) {
+	tests := []struct {
+		description string
+		status      int
+		challenge   string
+		expectRetry bool
+		expectFetch int
+	}{
+		{
+			description: "Bearer challenge forces a new token",
+			status:      http.StatusUnauthorized,
+			challenge:   `Bearer realm="example", error="invalid_token"`,
+			expectRetry: true,
+			expectFetch: 2,
+		},
+		{
+			description: "Bearer challenge among others",
+			status:      http.StatusUnauthorized,
+			challenge:   `Basic realm="example", Bearer`,
+			expectRetry: true,
+			expectFetch: 2,
+		},
+		{
+			description: "Other challenges are ignored",
+			status:      http.StatusUnauthorized,
+			challenge:   `Basic realm="example"`,
+			expectFetch: 1,
+		},
+		{
+			description: "Other status codes are ignored",
+			status:      http.StatusForbidden,
+			challenge:   `Bearer error="insufficient_scope"`,
+			expectFetch: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var fetches int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches++
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, fetches)
+			}))
+			defer server.Close()
+
+			var forced []bool
+			got, err := New(Config{
+				ClientID:     "test-client",
+				ClientSecret: "test-secret",
+				TokenURL:     server.URL,
+			}, func(evnt any) {
+				if e, ok := evnt.(events.FetchEvent); ok {
+					forced = append(forced, e.Forced)
+				}
+			})
+			require.NoError(err)
+
+			req, err := http.NewRequest("GET", "https://example.com/resource", nil)
+			require.NoError(err)
+			require.NoError(got.Decorate(req))
+			assert.Equal("Bearer token-1", req.Header.Get("Authorization"))
+
+			resp := &http.Response{
+				StatusCode: tc.status,
+				Header:     http.Header{"Www-Authenticate": {tc.challenge}},
+			}
+			assert.Equal(tc.expectRetry, got.Challenge(req, resp))
+
+			// A second rejection of the same token must not cause another fetch.
+			got.Challenge(req, resp)
+
+			require.NoError(got.Decorate(req))
+			assert.Equal(fmt.Sprintf("Bearer token-%d", tc.expectFetch), req.Header.Get("Authorization"))
+			assert.Equal(tc.expectFetch, fetches)
+
+			require.Len(forced, tc.expectFetch)
+			assert.False(forced[0])
+			if tc.expectFetch > 1 {
+				assert.True(forced[1])
+			}
+		})
+	}
+}
+
+func TestOAuthContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		n := fetches.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, n)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	got, err := New(Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		TokenURL:     server.URL,
+	}, nil)
+	require.NoError(err)
+
+	decorate := func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://example.com/resource", nil)
+		require.NoError(err)
+		err = got.Decorate(req)
+		return req.Header.Get("Authorization"), err
+	}
+
+	// A caller that gives up leaves the fetch running for the other waiter.
+	patient := make(chan string)
+	go func() {
+		auth, err := decorate(context.Background())
+		assert.NoError(err)
+		patient <- auth
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = decorate(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+
+	release <- struct{}{}
+	assert.Equal("Bearer token-1", <-patient)
+	assert.Equal(int32(1), fetches.Load())
+
+	// The cached token is returned even to a canceled caller.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	auth, err := decorate(ctx)
+	assert.NoError(err)
+	assert.Equal("Bearer token-1", auth)
+
+	// When every waiter gives up, the fetch is canceled and the next caller
+	// starts a new one.
+	got.ts.invalidate("token-1")
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		for fetches.Load() < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	_, err = decorate(ctx)
+	assert.ErrorIs(err, context.Canceled)
+	<-canceled
+
+	go func() {
+		release <- struct{}{}
+	}()
+	auth, err = decorate(context.Background())
+	assert.NoError(err)
+	assert.Equal("Bearer token-3", auth)
+}
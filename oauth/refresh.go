@@ -0,0 +1,269 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+var errNoRefreshToken = errors.New("a RefreshToken or stored tokens are required with the refresh_token grant")
+
+// TokenStore persists the refresh token of the refresh_token grant, so a
+// token rotated by the server survives a restart.
+type TokenStore interface {
+	// Load returns the stored refresh token, or "" if there is none.
+	Load() (string, error)
+
+	// Save replaces the stored refresh token.
+	Save(token string) error
+}
+
+// TokenSet holds the tokens issued by a login, such as DeviceLogin.
+type TokenSet struct {
+	AccessToken  string    `json:"access_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+func tokenSet(tok *oauth2.Token) TokenSet {
+	return TokenSet{
+		AccessToken:  tok.AccessToken,
+		TokenType:    tok.TokenType,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	}
+}
+
+// token returns the access token of the set, or nil if it has expired.
+func (s TokenSet) token() *oauth2.Token {
+	tok := &oauth2.Token{
+		AccessToken: s.AccessToken,
+		TokenType:   s.TokenType,
+		Expiry:      s.Expiry,
+	}
+	if !tok.Valid() {
+		return nil
+	}
+	return tok
+}
+
+// tokenCache is implemented by the token stores that keep the whole token
+// set, so a cached access token is used until it expires.
+type tokenCache interface {
+	Read() (TokenSet, error)
+	Write(TokenSet) error
+}
+
+// FileStore is a TokenStore that keeps the tokens in a file readable only
+// by its owner. The file holds the JSON encoded TokenSet, or only a refresh
+// token as provisioned by an operator.
+type FileStore string
+
+var (
+	_ TokenStore = FileStore("")
+	_ tokenCache = FileStore("")
+)
+
+// Load returns the refresh token in the file, or "" if the file does not
+// exist.
+func (f FileStore) Load() (string, error) {
+	set, err := f.Read()
+	return set.RefreshToken, err
+}
+
+// Save replaces the refresh token in the file.
+func (f FileStore) Save(token string) error {
+	set, err := f.Read()
+	if err != nil {
+		return err
+	}
+	set.RefreshToken = token
+	return f.Write(set)
+}
+
+// Read returns the tokens in the file, which are empty if the file does not
+// exist.
+func (f FileStore) Read() (TokenSet, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return TokenSet{}, nil
+	}
+	if err != nil {
+		return TokenSet{}, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return TokenSet{RefreshToken: string(data)}, nil
+	}
+
+	var set TokenSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return TokenSet{}, fmt.Errorf("reading %s: %w", f, err)
+	}
+	return set, nil
+}
+
+// Write replaces the file with one holding the tokens. The file is replaced
+// atomically so a crash never leaves a partial token behind.
+func (f FileStore) Write(set TokenSet) error {
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(string(f)), "."+filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// refresher holds the current refresh token and persists the ones issued by
+// the server.
+type refresher struct {
+	store TokenStore
+
+	// cached is the unexpired access token of the store, if any.
+	cached *oauth2.Token
+
+	// redeem is held for the whole of a redemption, so a refresh token is
+	// never redeemed twice concurrently.
+	redeem sync.Mutex
+
+	mu    sync.Mutex
+	token string
+}
+
+func newRefresher(token string, store TokenStore) (*refresher, error) {
+	r := refresher{
+		store: store,
+		token: token,
+	}
+
+	var stored string
+	var err error
+	if c, ok := store.(tokenCache); ok {
+		var set TokenSet
+		set, err = c.Read()
+		stored, r.cached = set.RefreshToken, set.token()
+	} else if store != nil {
+		stored, err = store.Load()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading the refresh token: %w", err)
+	}
+
+	// A stored token replaces the initial one it was rotated from.
+	if stored != "" {
+		r.token = stored
+	}
+	if r.token == "" && r.cached == nil {
+		return nil, errNoRefreshToken
+	}
+
+	return &r, nil
+}
+
+func (r *refresher) current() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.token
+}
+
+// request returns the token request configuration for the refresh token.
+func (r *refresher) request(base func() *clientcredentials.Config, token string) func() *clientcredentials.Config {
+	return func() *clientcredentials.Config {
+		cfg := base()
+
+		params := url.Values{}
+		for k, v := range cfg.EndpointParams {
+			params[k] = v
+		}
+		params.Set("grant_type", GrantRefreshToken)
+		params.Set("refresh_token", token)
+
+		cfg.EndpointParams = params
+		return cfg
+	}
+}
+
+// fetch returns a function that redeems the current refresh token. The
+// returned tokens only carry a RefreshToken when the server rotated it. A
+// store that caches the whole token set is updated on every fetch.
+//
+// Redemptions run one at a time and are not canceled with the context: a
+// server that rotates the refresh token may revoke the old one as soon as it
+// answers, so a redemption abandoned before its result is saved would lose
+// the only valid token.
+func (r *refresher) fetch(
+	base func() *clientcredentials.Config,
+	newFetch func(func() *clientcredentials.Config) func(context.Context) (*oauth2.Token, error),
+) func(context.Context) (*oauth2.Token, error) {
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		r.redeem.Lock()
+		defer r.redeem.Unlock()
+
+		ctx = context.WithoutCancel(ctx)
+		token := r.current()
+		if token == "" {
+			return nil, errNoRefreshToken
+		}
+
+		tok, err := newFetch(r.request(base, token))(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rotated := tok.RefreshToken != "" && tok.RefreshToken != token
+		if rotated {
+			// The old refresh token may no longer be accepted, so the new
+			// one is used even if it cannot be saved.
+			r.mu.Lock()
+			r.token = tok.RefreshToken
+			r.mu.Unlock()
+		} else {
+			tok.RefreshToken = token
+		}
+
+		if c, ok := r.store.(tokenCache); ok {
+			err = c.Write(tokenSet(tok))
+		} else if rotated && r.store != nil {
+			err = r.store.Save(tok.RefreshToken)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saving the refresh token: %w", err)
+		}
+
+		if !rotated {
+			tok.RefreshToken = ""
+		}
+		return tok, nil
+	}
+}
@@ -0,0 +1,398 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+)
+
+type failingStore struct {
+	token string
+}
+
+func (f failingStore) Load() (string, error) { return f.token, nil }
+func (failingStore) Save(string) error       { return errors.New("read-only") }
+
+func TestFileStore(t *testing.T) {
+	store := FileStore(filepath.Join(t.TempDir(), "refresh"))
+
+	token, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, store.Save("first"))
+	require.NoError(t, store.Save("second"))
+
+	token, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "second", token)
+
+	info, err := os.Stat(string(store))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(string(store)))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileStoreTokenSet(t *testing.T) {
+	store := FileStore(filepath.Join(t.TempDir(), "tokens"))
+
+	// A provisioned file only holds the refresh token.
+	require.NoError(t, os.WriteFile(string(store), []byte("provisioned\n"), 0o600))
+	set, err := store.Read()
+	require.NoError(t, err)
+	assert.Equal(t, TokenSet{RefreshToken: "provisioned"}, set)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, store.Write(TokenSet{
+		AccessToken:  "access",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		Expiry:       expiry,
+	}))
+
+	// Saving a refresh token keeps the rest of the set.
+	require.NoError(t, store.Save("rotated"))
+	set, err = store.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "access", set.AccessToken)
+	assert.Equal(t, "rotated", set.RefreshToken)
+	assert.True(t, expiry.Equal(set.Expiry))
+
+	require.NoError(t, os.WriteFile(string(store), []byte("{"), 0o600))
+	_, err = store.Read()
+	assert.Error(t, err)
+}
+
+func TestNewRefresher(t *testing.T) {
+	dir := t.TempDir()
+	stored := FileStore(filepath.Join(dir, "stored"))
+	require.NoError(t, stored.Save("stored"))
+	cached := FileStore(filepath.Join(dir, "cached"))
+	require.NoError(t, cached.Write(TokenSet{AccessToken: "access", Expiry: time.Now().Add(time.Hour)}))
+	expired := FileStore(filepath.Join(dir, "expired"))
+	require.NoError(t, expired.Write(TokenSet{AccessToken: "access", Expiry: time.Now().Add(-time.Hour)}))
+
+	tests := []struct {
+		description string
+		token       string
+		store       TokenStore
+		expect      string
+		expectError bool
+	}{
+		{
+			description: "Initial refresh token",
+			token:       "initial",
+			expect:      "initial",
+		},
+		{
+			description: "Stored refresh token",
+			token:       "initial",
+			store:       stored,
+			expect:      "stored",
+		},
+		{
+			description: "Empty store",
+			token:       "initial",
+			store:       FileStore(filepath.Join(dir, "missing")),
+			expect:      "initial",
+		},
+		{
+			description: "Cached access token",
+			store:       cached,
+			expect:      "",
+		},
+		{
+			description: "Expired access token",
+			store:       expired,
+			expectError: true,
+		},
+		{
+			description: "No refresh token",
+			store:       FileStore(filepath.Join(dir, "missing")),
+			expectError: true,
+		},
+		{
+			description: "Unreadable store",
+			token:       "initial",
+			store:       FileStore(dir),
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			r, err := newRefresher(tc.token, tc.store)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, r.current())
+		})
+	}
+}
+
+func TestOAuthRefreshToken(t *testing.T) {
+	// The server rotates the refresh token on every other use.
+	var fetches int
+	var rotate bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, GrantRefreshToken, r.PostForm.Get("grant_type"))
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		fetches++
+
+		refresh := r.PostForm.Get("refresh_token")
+		if rotate {
+			refresh = fmt.Sprintf("refresh-%d", fetches)
+		}
+		rotate = !rotate
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access-%d", "token_type": "Bearer", "refresh_token": %q, "expires_in": 3600}`,
+			fetches, refresh)
+	}))
+	defer server.Close()
+
+	store := FileStore(filepath.Join(t.TempDir(), "refresh"))
+
+	var fetchEvents []events.FetchEvent
+	var sent []string
+	o, err := New(Config{
+		GrantType:        GrantRefreshToken,
+		ClientID:         "client",
+		TokenURL:         server.URL,
+		RefreshToken:     "refresh-0",
+		RefreshTokenFile: string(store),
+	}, func(evnt any) {
+		if e, ok := evnt.(events.FetchEvent); ok {
+			fetchEvents = append(fetchEvents, e)
+		}
+	})
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	challenge := func() {
+		req := httptest.NewRequest("GET", "https://example.com", nil)
+		require.NoError(t, o.Decorate(req))
+		sent = append(sent, req.Header.Get("Authorization"))
+		assert.True(t, o.Challenge(req, &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Header:     http.Header{"Www-Authenticate": {"Bearer"}},
+		}))
+	}
+
+	for range 3 {
+		challenge()
+	}
+	assert.Equal(t, []string{"Bearer access-1", "Bearer access-2", "Bearer access-3"}, sent)
+
+	require.Len(t, fetchEvents, 3)
+	assert.False(t, fetchEvents[0].Rotated)
+	assert.True(t, fetchEvents[1].Rotated)
+	assert.False(t, fetchEvents[2].Rotated)
+
+	token, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-2", token)
+}
+
+func TestOAuthRefreshTokenSaveError(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		got = append(got, r.PostForm.Get("refresh_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access", "token_type": "Bearer", "refresh_token": "refresh-%d", "expires_in": 3600}`,
+			len(got))
+	}))
+	defer server.Close()
+
+	o, err := New(Config{
+		GrantType:         GrantRefreshToken,
+		TokenURL:          server.URL,
+		RefreshTokenStore: failingStore{token: "refresh-0"},
+	}, nil)
+	require.NoError(t, err)
+
+	for range 2 {
+		req := httptest.NewRequest("GET", "https://example.com", nil)
+		assert.ErrorContains(t, o.Decorate(req), "read-only")
+	}
+
+	// The rotated token is used even though it could not be saved.
+	assert.Equal(t, []string{"refresh-0", "refresh-1"}, got)
+}
+
+func TestOAuthRotatedOtherGrants(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "access", "token_type": "Bearer", "refresh_token": "refresh", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+
+	for _, grant := range []string{GrantClientCredentials, GrantTokenExchange} {
+		t.Run(grant, func(t *testing.T) {
+			var fetchEvents []events.FetchEvent
+			o, err := New(Config{
+				GrantType: grant,
+				ClientID:  "client",
+				TokenURL:  server.URL,
+			}, func(evnt any) {
+				if e, ok := evnt.(events.FetchEvent); ok {
+					fetchEvents = append(fetchEvents, e)
+				}
+			})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "https://example.com", nil)
+			req = req.WithContext(WithSubjectToken(req.Context(), "subject"))
+			require.NoError(t, o.Decorate(req))
+
+			// A refresh token issued by another grant is not a rotation.
+			require.Len(t, fetchEvents, 1)
+			assert.False(t, fetchEvents[0].Rotated)
+		})
+	}
+}
+
+func TestOAuthRefreshTokenCanceled(t *testing.T) {
+	received := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	var mu sync.Mutex
+	var got []string
+	var active, maxActive int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		mu.Lock()
+		got = append(got, r.PostForm.Get("refresh_token"))
+		n := len(got)
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+
+		received <- struct{}{}
+		<-release
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access-%d", "token_type": "Bearer", "refresh_token": "refresh-%d", "expires_in": 3600}`,
+			n, n)
+	}))
+	defer server.Close()
+
+	store := FileStore(filepath.Join(t.TempDir(), "refresh"))
+	o, err := New(Config{
+		GrantType:        GrantRefreshToken,
+		TokenURL:         server.URL,
+		RefreshToken:     "refresh-0",
+		RefreshTokenFile: string(store),
+	}, nil)
+	require.NoError(t, err)
+
+	// The caller gives up while the refresh token is being redeemed.
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "https://example.com", nil).WithContext(ctx)
+	go func() {
+		<-received
+		cancel()
+	}()
+	assert.ErrorIs(t, o.Decorate(req), context.Canceled)
+
+	// A new caller waits for the abandoned redemption instead of redeeming
+	// the same refresh token concurrently.
+	done := make(chan string)
+	go func() {
+		req := httptest.NewRequest("GET", "https://example.com", nil)
+		assert.NoError(t, o.Decorate(req))
+		done <- req.Header.Get("Authorization")
+	}()
+
+	close(release)
+	assert.Equal(t, "Bearer access-2", <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"refresh-0", "refresh-1"}, got)
+	assert.Equal(t, 1, maxActive)
+
+	token, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-2", token)
+}
+
+func TestOAuthRefreshTokenCache(t *testing.T) {
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "refresh", r.PostForm.Get("refresh_token"))
+		fetches++
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "access-%d", "token_type": "Bearer", "expires_in": 3600}`, fetches)
+	}))
+	defer server.Close()
+
+	store := FileStore(filepath.Join(t.TempDir(), "tokens"))
+	require.NoError(t, store.Write(TokenSet{
+		AccessToken:  "cached",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(time.Hour),
+	}))
+
+	config := Config{
+		GrantType:        GrantRefreshToken,
+		TokenURL:         server.URL,
+		RefreshTokenFile: string(store),
+	}
+	o, err := New(config, nil)
+	require.NoError(t, err)
+
+	// The cached access token is used until it is rejected.
+	req := httptest.NewRequest("GET", "https://example.com", nil)
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer cached", req.Header.Get("Authorization"))
+	assert.Zero(t, fetches)
+
+	assert.True(t, o.Challenge(req, &http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Header:     http.Header{"Www-Authenticate": {"Bearer"}},
+	}))
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer access-1", req.Header.Get("Authorization"))
+
+	// The new access token is cached for the next instance.
+	set, err := store.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", set.AccessToken)
+	assert.Equal(t, "refresh", set.RefreshToken)
+
+	o, err = New(config, nil)
+	require.NoError(t, err)
+	require.NoError(t, o.Decorate(req))
+	assert.Equal(t, "Bearer access-1", req.Header.Get("Authorization"))
+	assert.Equal(t, 1, fetches)
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"golang.org/x/oauth2"
+)
+
+// maxThemisResponse is the largest token accepted from a Themis endpoint.
+const maxThemisResponse = 64 << 10
+
+var errTokenExpired = errors.New("the token endpoint returned an expired token")
+
+// themisFetch returns a function that fetches a raw JWT with an
+// authenticated GET of the token URL, as Themis issuers expect. The token
+// expires at its exp claim, or after the DefaultTokenDuration if it has
+// none.
+func themisFetch(config Config) func(context.Context) (*oauth2.Token, error) {
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.TokenURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range config.TokenHeaders {
+			req.Header[http.CanonicalHeaderKey(k)] = v
+		}
+		if config.ClientSecret != "" {
+			req.SetBasicAuth(config.ClientID, config.ClientSecret)
+		}
+
+		client := http.DefaultClient
+		if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+			client = c
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxThemisResponse))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("token endpoint returned %s", resp.Status)
+		}
+
+		tok := &oauth2.Token{
+			AccessToken: strings.TrimSpace(string(body)),
+			TokenType:   "Bearer",
+		}
+
+		exp, err := jwt.Expiry(tok.AccessToken)
+		switch {
+		case errors.Is(err, jwt.ErrNoExpiry):
+		case err != nil:
+			return nil, err
+		default:
+			// The lifetime is what the safety margin applies to.
+			tok.ExpiresIn = int64(time.Until(exp) / time.Second)
+			if tok.ExpiresIn <= 0 {
+				return nil, errTokenExpired
+			}
+		}
+		return tok, nil
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+func TestOAuthThemis(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	token := func(claims map[string]any) string {
+		tok, err := jwt.Sign(key, nil, claims)
+		require.NoError(t, err)
+		return tok
+	}
+	valid := token(map[string]any{"sub": "device", "exp": time.Now().Add(100 * time.Second).Unix()})
+	noExpiry := token(map[string]any{"sub": "device"})
+	expired := token(map[string]any{"sub": "device", "exp": time.Now().Add(-time.Minute).Unix()})
+
+	tests := []struct {
+		description      string
+		config           Config
+		status           int
+		body             string
+		expectToken      string
+		expectLifetime   time.Duration
+		expectExpiration time.Duration
+		expectError      bool
+	}{
+		{
+			description: "Basic credentials",
+			config: Config{
+				ClientID:     "user",
+				ClientSecret: "secret",
+			},
+			body:             valid + "\n",
+			expectToken:      valid,
+			expectLifetime:   100 * time.Second,
+			expectExpiration: 80 * time.Second,
+		},
+		{
+			description: "Header credentials",
+			config: Config{
+				TokenHeaders: http.Header{"X-Api-Key": {"key"}},
+			},
+			body:             valid,
+			expectToken:      valid,
+			expectLifetime:   100 * time.Second,
+			expectExpiration: 80 * time.Second,
+		},
+		{
+			description: "No exp claim",
+			config: Config{
+				ClientID:             "user",
+				ClientSecret:         "secret",
+				DefaultTokenDuration: time.Hour,
+			},
+			body:             noExpiry,
+			expectToken:      noExpiry,
+			expectLifetime:   time.Hour,
+			expectExpiration: 48 * time.Minute,
+		},
+		{
+			description: "Expired token",
+			config:      Config{ClientID: "user", ClientSecret: "secret"},
+			body:        expired,
+			expectError: true,
+		},
+		{
+			description: "Not a JWT",
+			config:      Config{ClientID: "user", ClientSecret: "secret"},
+			body:        "not a token",
+			expectError: true,
+		},
+		{
+			description: "Rejected credentials",
+			config:      Config{ClientID: "user", ClientSecret: "wrong"},
+			status:      http.StatusUnauthorized,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				user, pass, ok := r.BasicAuth()
+				assert.Equal(t, tc.config.ClientSecret != "", ok)
+				assert.Equal(t, tc.config.ClientID, user)
+				assert.Equal(t, tc.config.ClientSecret, pass)
+				assert.Equal(t, tc.config.TokenHeaders.Get("X-Api-Key"), r.Header.Get("X-Api-Key"))
+
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			var fetchEvents []events.FetchEvent
+			tc.config.GrantType = GrantThemis
+			tc.config.TokenURL = server.URL
+			tc.config.ExpirationSafetyMargin = 0.8
+			o, err := New(tc.config, func(evnt any) {
+				if e, ok := evnt.(events.FetchEvent); ok {
+					fetchEvents = append(fetchEvents, e)
+				}
+			})
+			require.NoError(t, err)
+			require.NotNil(t, o)
+
+			req := httptest.NewRequest("GET", "https://example.com", nil)
+			err = o.Decorate(req)
+			require.Len(t, fetchEvents, 1)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Error(t, fetchEvents[0].Err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer "+tc.expectToken, req.Header.Get("Authorization"))
+
+			e := fetchEvents[0]
+			assert.NoError(t, e.Err)
+			assert.WithinDuration(t, e.At.Add(tc.expectLifetime), e.OriginalExpiration, 2*time.Second)
+			assert.WithinDuration(t, e.At.Add(tc.expectExpiration), e.Expiration, 2*time.Second)
+		})
+	}
+}
+
+func TestNewThemis(t *testing.T) {
+	for _, config := range []Config{
+		{GrantType: GrantThemis, TokenURL: "https://example.com/issue", DPoP: true},
+		{GrantType: GrantThemis, TokenURL: "https://example.com/issue", ClientID: "id", AuthStyle: privateKeyJWT},
+	} {
+		_, err := New(config, nil)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/xmidt-org/vouch/internal/filestamp"
+	"github.com/xmidt-org/vouch/internal/tlstransport"
+	"golang.org/x/oauth2"
+)
+
+// tlsClientAuth is the AuthStyle that authenticates the client with its TLS
+// certificate rather than a secret, as described in RFC 8705.
+const tlsClientAuth = "tls_client_auth"
+
+var errNoCACertificates = errors.New("no CA certificates found")
+
+// TLS configures mutual TLS with the token endpoint. The files are read again
+// when they change, so certificates can be rotated in place.
+type TLS struct {
+	// CertFile is the path of the PEM encoded client certificate, followed
+	// by any intermediate certificates.
+	CertFile string
+
+	// KeyFile is the path of the PEM encoded private key of the client
+	// certificate.
+	KeyFile string
+
+	// CAFile is the optional path of the PEM encoded CA certificates used to
+	// verify servers. The system roots are used by default.
+	CAFile string
+}
+
+func (c TLS) isActive() bool {
+	return c.CertFile != "" || c.KeyFile != "" || c.CAFile != ""
+}
+
+// clientTLS provides the client certificate and server roots of a TLS
+// configuration, reloading them when their files change.
+type clientTLS struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu        sync.Mutex
+	certStamp filestamp.Stamp
+	keyStamp  filestamp.Stamp
+	cert      *tls.Certificate
+	caStamp   filestamp.Stamp
+	rootCAs   *x509.CertPool
+}
+
+func newClientTLS(c TLS) (*clientTLS, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("TLS CertFile and KeyFile must be set together")
+	}
+
+	t := clientTLS{
+		certFile: c.CertFile,
+		keyFile:  c.KeyFile,
+		caFile:   c.CAFile,
+	}
+
+	// Load the files now so configuration errors are reported early.
+	if t.certFile != "" {
+		if _, err := t.certificate(nil); err != nil {
+			return nil, err
+		}
+	}
+	if t.caFile != "" {
+		if _, err := t.pool(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &t, nil
+}
+
+// certificate returns the current client certificate. It matches the
+// signature of tls.Config.GetClientCertificate.
+func (t *clientTLS) certificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	certStamp, err := filestamp.Stat(t.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyStamp, err := filestamp.Stat(t.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cert != nil && certStamp.Equal(t.certStamp) && keyStamp.Equal(t.keyStamp) {
+		return t.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS client certificate %s: %w", t.certFile, err)
+	}
+
+	t.cert, t.certStamp, t.keyStamp = &cert, certStamp, keyStamp
+	return t.cert, nil
+}
+
+// pool returns the current CA certificates.
+func (t *clientTLS) pool() (*x509.CertPool, error) {
+	stamp, err := filestamp.Stat(t.caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rootCAs != nil && stamp.Equal(t.caStamp) {
+		return t.rootCAs, nil
+	}
+
+	data, err := os.ReadFile(t.caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("invalid TLS CAFile %s: %w", t.caFile, errNoCACertificates)
+	}
+
+	t.rootCAs, t.caStamp = roots, stamp
+	return roots, nil
+}
+
+// roots returns the current CA certificates, or the last ones loaded if the
+// CA file cannot be read while it is being replaced.
+func (t *clientTLS) roots() *x509.CertPool {
+	if pool, err := t.pool(); err == nil {
+		return pool
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rootCAs
+}
+
+// config returns a tls.Config that presents the client certificate and
+// verifies servers using the CA certificates current at the time of the
+// call. The certificate is obtained again for each connection.
+func (t *clientTLS) config() *tls.Config {
+	cfg := tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if t.certFile != "" {
+		cfg.GetClientCertificate = t.certificate
+	}
+	if t.caFile != "" {
+		cfg.RootCAs = t.roots()
+	}
+	return &cfg
+}
+
+// client returns an HTTP client for the token endpoint that uses the TLS
+// configuration, following changes to the CA certificates.
+func (t *clientTLS) client() *http.Client {
+	return &http.Client{
+		Transport: tlstransport.New(t.config),
+	}
+}
+
+// withClient makes the fetch use the HTTP client to reach the token endpoint.
+func withClient(fetch func(context.Context) (*oauth2.Token, error), client *http.Client) func(context.Context) (*oauth2.Token, error) {
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		return fetch(context.WithValue(ctx, oauth2.HTTPClient, client))
+	}
+}
@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues client certificates for the tests.
+type testCA struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	pem    []byte
+	issued int
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue writes a new client certificate and its key to the files.
+func (ca *testCA) issue(t *testing.T, name, certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	// Make sure the change is noticed even on coarse file systems.
+	ca.issued++
+	later := time.Now().Add(time.Duration(ca.issued) * time.Second)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+}
+
+// newMTLSServer starts a server that requires a client certificate issued by
+// the CA and answers with the common name of the client. It returns the
+// server and the path of a CA file that trusts it.
+func newMTLSServer(t *testing.T, ca *testCA, handler func(w http.ResponseWriter, r *http.Request, name string)) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A new connection is used for every request so each one presents
+		// the current certificate.
+		w.Header().Set("Connection", "close")
+		handler(w, r, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	// Rejected handshakes are expected.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "server-ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	return server, caFile
+}
+
+func TestNewTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ca := newTestCA(t)
+	ca.issue(t, "client", certFile, keyFile)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	tests := []struct {
+		description string
+		style       string
+		secret      string
+		tls         TLS
+		expectError bool
+	}{
+		{
+			description: "Client certificate",
+			style:       "tls_client_auth",
+			tls:         TLS{CertFile: certFile, KeyFile: keyFile, CAFile: caFile},
+		},
+		{
+			description: "Only a CA file",
+			tls:         TLS{CAFile: caFile},
+		},
+		{
+			description: "Client certificate without a key",
+			tls:         TLS{CertFile: certFile},
+			expectError: true,
+		},
+		{
+			description: "Missing certificate file",
+			tls:         TLS{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile},
+			expectError: true,
+		},
+		{
+			description: "Key that does not match",
+			tls:         TLS{CertFile: caFile, KeyFile: keyFile},
+			expectError: true,
+		},
+		{
+			description: "Invalid CA file",
+			tls:         TLS{CAFile: keyFile},
+			expectError: true,
+		},
+		{
+			description: "tls_client_auth without a certificate",
+			style:       "tls_client_auth",
+			tls:         TLS{CAFile: caFile},
+			expectError: true,
+		},
+		{
+			description: "tls_client_auth with a secret",
+			style:       "tls_client_auth",
+			secret:      "test-secret",
+			tls:         TLS{CertFile: certFile, KeyFile: keyFile},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := New(Config{
+				ClientID:     "test-client",
+				ClientSecret: tc.secret,
+				TokenURL:     "https://example.com/token",
+				AuthStyle:    tc.style,
+				TLS:          tc.tls,
+			}, nil)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, got.TLSConfig())
+		})
+	}
+
+	o, err := New(Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		TokenURL:     "https://example.com/token",
+	}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, o.TLSConfig())
+}
+
+func TestOAuthTLSClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ca := newTestCA(t)
+	ca.issue(t, "client-1", certFile, keyFile)
+
+	server, caFile := newMTLSServer(t, ca, func(w http.ResponseWriter, r *http.Request, name string) {
+		if r.URL.Path != "/token" {
+			w.Write([]byte(name))
+			return
+		}
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "test-client", r.PostForm.Get("client_id"))
+		assert.False(t, r.PostForm.Has("client_secret"))
+		_, _, ok := r.BasicAuth()
+		assert.False(t, ok)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "` + name + `", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+
+	o, err := New(Config{
+		ClientID:  "test-client",
+		TokenURL:  server.URL + "/token",
+		AuthStyle: "tls_client_auth",
+		TLS: TLS{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			CAFile:   caFile,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = o.TLSConfig()
+	client := &http.Client{Transport: transport}
+
+	for _, name := range []string{"client-1", "client-2"} {
+		if name != "client-1" {
+			// Rotate the certificate in place and drop the bound token.
+			ca.issue(t, name, certFile, keyFile)
+			req, err := http.NewRequest("GET", server.URL, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer client-1")
+			require.True(t, o.Challenge(req, &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Header:     http.Header{"Www-Authenticate": {"Bearer"}},
+			}))
+		}
+
+		req, err := http.NewRequest("GET", server.URL+"/resource", nil)
+		require.NoError(t, err)
+		require.NoError(t, o.Decorate(req))
+		assert.Equal(t, "Bearer "+name, req.Header.Get("Authorization"))
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		resp.Body.Close()
+		assert.Equal(t, name, string(body[:n]))
+	}
+}
+
+func TestOAuthTLSUntrustedServer(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ca := newTestCA(t)
+	ca.issue(t, "client", certFile, keyFile)
+
+	server, _ := newMTLSServer(t, ca, func(w http.ResponseWriter, r *http.Request, name string) {
+		t.Error("the server should not be trusted")
+	})
+
+	// The CA of the client certificates does not issue server certificates.
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	o, err := New(Config{
+		ClientID:  "test-client",
+		TokenURL:  server.URL + "/token",
+		AuthStyle: "tls_client_auth",
+		TLS: TLS{
+			CertFile: certFile,
+			KeyFile:  keyFile,
+			CAFile:   caFile,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", server.URL+"/resource", nil)
+	require.NoError(t, err)
+	assert.Error(t, o.Decorate(req))
+}
+
+func TestOAuthTLSServerName(t *testing.T) {
+	tests := []struct {
+		description string
+		dnsNames    []string
+		ips         []net.IP
+		expectError bool
+	}{
+		{
+			description: "Certificate for the address dialed",
+			ips:         []net.IP{net.IPv4(127, 0, 0, 1)},
+		},
+		{
+			description: "Certificate for another host",
+			dnsNames:    []string{"other.example"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			ca := newTestCA(t)
+
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      pkix.Name{CommonName: "server"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				DNSNames:     tc.dnsNames,
+				IPAddresses:  tc.ips,
+			}, ca.cert, &key.PublicKey, ca.key)
+			require.NoError(t, err)
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+			}))
+			server.Config.ErrorLog = log.New(io.Discard, "", 0)
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			}
+			server.StartTLS()
+			defer server.Close()
+
+			caFile := filepath.Join(t.TempDir(), "ca.pem")
+			require.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+			o, err := New(Config{
+				ClientID:     "test-client",
+				ClientSecret: "test-secret",
+				TokenURL:     server.URL + "/token",
+				TLS:          TLS{CAFile: caFile},
+			}, nil)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", server.URL+"/resource", nil)
+			require.NoError(t, err)
+			if tc.expectError {
+				assert.Error(t, o.Decorate(req))
+			} else {
+				assert.NoError(t, o.Decorate(req))
+			}
+
+			// Resource servers are verified the same way.
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = o.TLSConfig()
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/resource")
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				resp.Body.Close()
+			}
+		})
+	}
+}
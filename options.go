@@ -4,11 +4,22 @@
 package vouch
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/xmidt-org/vouch/apikey"
 	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/bearer"
+	"github.com/xmidt-org/vouch/digest"
 	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/httpsig"
 	"github.com/xmidt-org/vouch/oauth"
+	"github.com/xmidt-org/vouch/serviceaccount"
+	"github.com/xmidt-org/vouch/sigv4"
 )
 
 type optFuncErr func(*Vouch) error
@@ -58,8 +69,82 @@ func WithDecorateEventListener(listener events.DecorateEventListener, cancel ...
 	})
 }
 
+// WithRedirectEventListener adds a RedirectEventListener to the Auth instance.
+// It returns a cancel function that can be used to remove the listener.
+// If the listener is nil, it does nothing.
+func WithRedirectEventListener(listener events.RedirectEventListener, cancel ...*func()) Option {
+	return optFunc(func(a *Vouch) {
+		if listener == nil {
+			return
+		}
+		c := a.redirectListeners.Add(listener)
+		for _, cancelFunc := range cancel {
+			if cancelFunc != nil {
+				*cancelFunc = c
+			}
+		}
+	})
+}
+
+// WithProxyFunc sets how Vouch.Decorate finds the egress proxy a request is
+// sent through, which is nil if the request is sent directly. It has the
+// signature of http.Transport.Proxy. The default is
+// http.ProxyFromEnvironment; a nil function means requests are never sent
+// through a proxy.
+func WithProxyFunc(proxy func(*http.Request) (*url.URL, error)) Option {
+	return optFunc(func(a *Vouch) {
+		a.proxyFor = proxy
+	})
+}
+
 // -----------------------------------------------------------------------------
 
+func handleInsecureHosts(hosts []string) Option {
+	return optFunc(func(a *Vouch) {
+		a.insecureHosts = normalizeHosts(hosts)
+	})
+}
+
+func checkTokenURLs(cfg Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		configs := []oauth.Config{cfg.OAuth}
+		for _, r := range cfg.Routes {
+			configs = append(configs, r.OAuth)
+		}
+
+		for _, o := range configs {
+			if !o.IsActive() {
+				continue
+			}
+			u, err := url.Parse(o.TokenURL)
+			if err != nil {
+				return err
+			}
+			if err := a.checkTransport(u); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func handleRedirectHosts(hosts []string) Option {
+	return optFunc(func(a *Vouch) {
+		a.redirectHosts = normalizeHosts(hosts)
+	})
+}
+
+// normalizeHosts lower cases the hosts and drops empty entries.
+func normalizeHosts(hosts []string) []string {
+	var normalized []string
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized
+}
+
 func handleBasic(b basic.Config) Option {
 	return optFunc(func(a *Vouch) {
 		a.basic = basic.New(b, a.dispatch)
@@ -74,17 +159,150 @@ func handleOAuth(o oauth.Config) Option {
 	})
 }
 
+func handleBearer(b bearer.Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		var err error
+		a.bearer, err = bearer.New(b, a.dispatch)
+		return err
+	})
+}
+
+func handleAPIKey(k apikey.Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		var err error
+		a.apikey, err = apikey.New(k, a.dispatch)
+		return err
+	})
+}
+
+func handleSigV4(s sigv4.Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		var err error
+		a.sigv4, err = sigv4.New(s, a.dispatch)
+		return err
+	})
+}
+
+func handleHTTPSig(h httpsig.Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		var err error
+		a.httpsig, err = httpsig.New(h, a.dispatch)
+		return err
+	})
+}
+
+func handleDigest(d digest.Config) Option {
+	return optFunc(func(a *Vouch) {
+		a.digest = digest.New(d, a.dispatch)
+	})
+}
+
+func handleServiceAccount(s serviceaccount.Config) Option {
+	return optFuncErr(func(a *Vouch) error {
+		var err error
+		a.serviceAccount, err = serviceaccount.New(s, a.dispatch)
+		return err
+	})
+}
+
+func handleProxy(p basic.Config) Option {
+	return optFunc(func(a *Vouch) {
+		p.Proxy = true
+		a.proxy = basic.New(p, a.dispatch)
+	})
+}
+
+func handleRoutes(routes []Route) Option {
+	return optFuncErr(func(a *Vouch) error {
+		for i, r := range routes {
+			decorators, proxies, err := r.decorators(a.dispatch)
+			if err != nil {
+				return fmt.Errorf("route %d: %w", i, err)
+			}
+			a.routes = append(a.routes, route{
+				scheme:     strings.ToLower(r.Scheme),
+				host:       strings.ToLower(r.Host),
+				port:       r.Port,
+				pathPrefix: r.PathPrefix,
+				decorators: decorators,
+				proxies:    proxies,
+			})
+		}
+		return nil
+	})
+}
+
 func setupDecorators() Option {
 	return optFunc(func(a *Vouch) {
+		var all []decorator
 		if a.basic != nil {
-			a.decorators = append(a.decorators, a.basic)
+			all = append(all, a.basic)
 		}
 		if a.oauth != nil {
-			a.decorators = append(a.decorators, a.oauth)
+			all = append(all, a.oauth)
+		}
+		if a.bearer != nil {
+			all = append(all, a.bearer)
+		}
+		if a.apikey != nil {
+			all = append(all, a.apikey)
+		}
+		if a.sigv4 != nil {
+			all = append(all, a.sigv4)
+		}
+		if a.httpsig != nil {
+			all = append(all, a.httpsig)
+		}
+		if a.digest != nil {
+			all = append(all, a.digest)
+		}
+		if a.serviceAccount != nil {
+			all = append(all, a.serviceAccount)
+		}
+		if a.proxy != nil {
+			all = append(all, a.proxy)
+		}
+
+		a.decorators, a.proxies = splitDecorators(all)
+	})
+}
+
+func setupRedactors() Option {
+	return optFunc(func(a *Vouch) {
+		all := append(slices.Clone(a.decorators), a.proxies...)
+		for _, r := range a.routes {
+			all = append(all, r.decorators...)
+			all = append(all, r.proxies...)
+		}
+
+		for _, d := range all {
+			if r, ok := d.(redactor); ok {
+				a.redactors = append(a.redactors, r)
+			}
 		}
+	})
+}
+
+// splitDecorators separates the decorators for an egress proxy from the
+// others, and sorts both by priority.
+func splitDecorators(all []decorator) (decorators, proxies []decorator) {
+	for _, d := range all {
+		if p, ok := d.(proxier); ok && p.Proxy() {
+			proxies = append(proxies, d)
+		} else {
+			decorators = append(decorators, d)
+		}
+	}
+
+	sortDecorators(decorators)
+	sortDecorators(proxies)
+	return decorators, proxies
+}
 
-		sort.Slice(a.decorators, func(i, j int) bool {
-			return a.decorators[i].Priority() > a.decorators[j].Priority()
-		})
+// sortDecorators orders the decorators from the highest to the lowest
+// priority.
+func sortDecorators(decorators []decorator) {
+	sort.Slice(decorators, func(i, j int) bool {
+		return decorators[i].Priority() > decorators[j].Priority()
 	})
 }
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+// proxier is implemented by decorators that may hold the credentials for an
+// egress proxy rather than for the destination.
+type proxier interface {
+	Proxy() bool
+}
+
+// proxyFunc returns the egress proxy of a request, or nil if it is sent
+// directly, like http.Transport.Proxy.
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+// decorateProxy applies every proxy decorator to a plaintext request that is
+// sent through an egress proxy. Requests to https destinations are tunneled
+// through the proxy with CONNECT, so their headers reach the destination
+// instead; they are left alone. Credentials are only sent to a plaintext
+// proxy listed in InsecureHosts.
+func (a *Vouch) decorateProxy(req *http.Request, proxies []decorator, proxy proxyFunc) error {
+	if len(proxies) == 0 || proxy == nil || strings.EqualFold(req.URL.Scheme, "https") {
+		return nil
+	}
+
+	u, err := proxy(req)
+	if err != nil || u == nil {
+		return err
+	}
+	if err := a.checkTransport(u); err != nil {
+		return err
+	}
+
+	errs := make([]error, 0, len(proxies))
+	for _, p := range proxies {
+		if err := p.Decorate(req); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProxyConnectHeader returns the headers to send to an egress proxy when
+// opening a CONNECT tunnel to the target host:port. It matches the signature
+// of http.Transport.GetProxyConnectHeader:
+//
+//	transport.GetProxyConnectHeader = v.ProxyConnectHeader
+//
+// The headers of every proxy decorator for the target are merged. Routes are
+// matched against the target as an https URL with an empty path. Credentials
+// are only sent to a plaintext proxy listed in InsecureHosts. The context
+// bounds how long it waits for credentials to be fetched.
+func (a *Vouch) ProxyConnectHeader(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error) {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: "none",
+	}
+
+	// A target that matches no route simply gets no proxy credentials.
+	_, proxies, _ := a.decoratorsFor(&http.Request{
+		URL: &url.URL{
+			Scheme: "https",
+			Host:   target,
+		},
+	})
+
+	h := http.Header{}
+	errs := make([]error, 0, len(proxies))
+	if len(proxies) > 0 && proxyURL != nil {
+		if err := a.checkTransport(proxyURL); err != nil {
+			proxies = nil
+			errs = append(errs, err)
+		}
+	}
+	for _, p := range proxies {
+		hp, ok := p.(headerer)
+		if !ok {
+			errs = append(errs, errNoHeaders)
+			continue
+		}
+		headers, err := hp.Headers(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for k, v := range headers {
+			h[k] = append(h[k], v...)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		evnt.Duration = time.Since(evnt.At)
+		evnt.Err = err
+		a.dispatch(evnt)
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,314 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/xmidt-org/vouch/basic"
+)
+
+func TestVouch_DecorateProxy(t *testing.T) {
+	tests := []struct {
+		description string
+		config      Config
+		proxy       string
+		url         string
+		expectAuth  string
+		expectProxy string
+		expectError bool
+	}{
+		{
+			description: "Both credentials on a plaintext request",
+			config: Config{
+				Basic:         basic.Config{Username: "user", Password: "pass"},
+				Proxy:         basic.Config{Username: "proxy", Password: "pass"},
+				InsecureHosts: []string{"example.com", "proxy.example.com"},
+			},
+			proxy:       "http://proxy.example.com:3128",
+			url:         "http://example.com/",
+			expectAuth:  "Basic dXNlcjpwYXNz",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "No proxy credentials on an https request",
+			config: Config{
+				Basic: basic.Config{Username: "user", Password: "pass"},
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			proxy:      "https://proxy.example.com",
+			url:        "https://example.com/",
+			expectAuth: "Basic dXNlcjpwYXNz",
+		},
+		{
+			description: "Only proxy credentials",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			proxy:       "https://proxy.example.com",
+			url:         "http://example.com/",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "No proxy credentials without a proxy",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			url: "http://example.com/",
+		},
+		{
+			description: "Proxy credentials of a route",
+			config: Config{
+				Routes: []Route{
+					{
+						Host:  "example.com",
+						Basic: basic.Config{Username: "proxy", Password: "pass", Proxy: true},
+					},
+				},
+			},
+			proxy:       "https://proxy.example.com",
+			url:         "http://example.com/",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "Default proxy credentials for a route",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+				Routes: []Route{
+					{
+						Host:  "example.com",
+						Basic: basic.Config{Username: "user", Password: "pass"},
+					},
+				},
+				InsecureHosts: []string{"example.com"},
+			},
+			proxy:       "https://proxy.example.com",
+			url:         "http://example.com/",
+			expectAuth:  "Basic dXNlcjpwYXNz",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "Route proxy credentials replace the defaults",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+				Routes: []Route{
+					{
+						Host:  "example.com",
+						Basic: basic.Config{Username: "route", Password: "pass", Proxy: true},
+					},
+				},
+			},
+			proxy:       "https://proxy.example.com",
+			url:         "http://example.com/",
+			expectProxy: "Basic cm91dGU6cGFzcw==",
+		},
+		{
+			description: "Plaintext proxy not in InsecureHosts",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			proxy:       "http://proxy.example.com:3128",
+			url:         "http://example.com/",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var proxy func(*http.Request) (*url.URL, error)
+			if tc.proxy != "" {
+				u, _ := url.Parse(tc.proxy)
+				proxy = http.ProxyURL(u)
+			}
+			v, err := New(tc.config, WithProxyFunc(proxy))
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			err = v.Decorate(req)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				if got := req.Header.Get("Proxy-Authorization"); got != "" {
+					t.Errorf("did not expect Proxy-Authorization but got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			if got := req.Header.Get("Authorization"); got != tc.expectAuth {
+				t.Errorf("expected Authorization %q but got %q", tc.expectAuth, got)
+			}
+			if got := req.Header.Get("Proxy-Authorization"); got != tc.expectProxy {
+				t.Errorf("expected Proxy-Authorization %q but got %q", tc.expectProxy, got)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTripProxy(t *testing.T) {
+	var gotAuth, gotProxy string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotProxy = r.Header.Get("Proxy-Authorization")
+	}))
+	defer proxy.Close()
+
+	v, err := New(Config{
+		Basic:         basic.Config{Username: "user", Password: "pass"},
+		Proxy:         basic.Config{Username: "proxy", Password: "pass"},
+		InsecureHosts: []string{"example.com", "127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := v.Client(&http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyURL(proxyURL),
+			GetProxyConnectHeader: v.ProxyConnectHeader,
+		},
+	})
+
+	resp, err := client.Get("http://example.com/")
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotAuth != "Basic dXNlcjpwYXNz" {
+		t.Errorf("unexpected Authorization: %q", gotAuth)
+	}
+	if gotProxy != "Basic cHJveHk6cGFzcw==" {
+		t.Errorf("unexpected Proxy-Authorization: %q", gotProxy)
+	}
+
+	// Without a proxy, the proxy credentials are not sent to the origin.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotProxy = r.Header.Get("Proxy-Authorization")
+	}))
+	defer server.Close()
+
+	gotProxy = ""
+	resp, err = v.Client(&http.Client{Transport: &http.Transport{}}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotProxy != "" {
+		t.Errorf("did not expect Proxy-Authorization but got %q", gotProxy)
+	}
+}
+
+func TestVouch_ProxyConnectHeader(t *testing.T) {
+	tests := []struct {
+		description string
+		config      Config
+		proxy       string
+		target      string
+		expectProxy string
+		expectError bool
+	}{
+		{
+			description: "Proxy credentials",
+			config: Config{
+				Basic: basic.Config{Username: "user", Password: "pass"},
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			proxy:       "https://proxy.example.com",
+			target:      "example.com:443",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "Plaintext proxy in InsecureHosts",
+			config: Config{
+				Proxy:         basic.Config{Username: "proxy", Password: "pass"},
+				InsecureHosts: []string{"proxy.example.com"},
+			},
+			proxy:       "http://proxy.example.com:3128",
+			target:      "example.com:443",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+		{
+			description: "Plaintext proxy not in InsecureHosts",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+			},
+			proxy:       "http://proxy.example.com:3128",
+			target:      "example.com:443",
+			expectError: true,
+		},
+		{
+			description: "No proxy credentials",
+			config: Config{
+				Basic: basic.Config{Username: "user", Password: "pass"},
+			},
+			target: "example.com:443",
+		},
+		{
+			description: "Target matching no route",
+			config: Config{
+				Routes: []Route{
+					{
+						Host:  "example.com",
+						Basic: basic.Config{Username: "proxy", Password: "pass", Proxy: true},
+					},
+				},
+			},
+			target: "other.example.com:443",
+		},
+		{
+			description: "Default proxy credentials for a route",
+			config: Config{
+				Proxy: basic.Config{Username: "proxy", Password: "pass"},
+				Routes: []Route{
+					{
+						Host:  "example.com",
+						Basic: basic.Config{Username: "user", Password: "pass"},
+					},
+				},
+			},
+			proxy:       "https://proxy.example.com",
+			target:      "example.com:443",
+			expectProxy: "Basic cHJveHk6cGFzcw==",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			v, err := New(tc.config)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			proxyURL, _ := url.Parse(tc.proxy)
+			h, err := v.ProxyConnectHeader(context.Background(), proxyURL, tc.target)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			if got := h.Get("Proxy-Authorization"); got != tc.expectProxy {
+				t.Errorf("expected Proxy-Authorization %q but got %q", tc.expectProxy, got)
+			}
+			if got := h.Get("Authorization"); got != "" {
+				t.Errorf("did not expect Authorization but got %q", got)
+			}
+		})
+	}
+}
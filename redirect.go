@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+// maxRedirects matches the limit of the http.Client default redirect policy.
+const maxRedirects = 10
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// credentialHeaders are removed from requests redirected to a host the
+// credentials are not scoped to.
+var credentialHeaders = []string{
+	"Authorization",
+}
+
+// stripper is implemented by decorators that place credentials outside of
+// the Authorization header, so they can be removed from a request.
+type stripper interface {
+	Strip(*http.Request)
+}
+
+// redactor is implemented by decorators that place credentials in the URL,
+// so they can be hidden when the URL is reported.
+type redactor interface {
+	Redact(*url.URL) *url.URL
+}
+
+// CheckRedirect is a redirect policy suitable for http.Client.CheckRedirect.
+//
+// The credentials are applied again when a request is redirected to the host
+// of the original request or to one of the configured RedirectHosts, and
+// removed from the request otherwise. A redirect from https to http always
+// removes the credentials. Like the default policy, it stops after 10
+// redirects.
+func (a *Vouch) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errTooManyRedirects
+	}
+	if len(via) == 0 {
+		return nil
+	}
+
+	_, err := a.redirect(req, via[0], via[len(via)-1], a.proxyFor)
+	return err
+}
+
+// redirect applies the redirect policy to req, which was redirected from prev
+// and was originally sent to origin. It reports whether the credentials were
+// applied.
+func (a *Vouch) redirect(req, origin, prev *http.Request, proxy proxyFunc) (bool, error) {
+	evnt := events.RedirectEvent{
+		At:      time.Now(),
+		From:    a.redact(prev.URL),
+		To:      a.redact(req.URL),
+		Allowed: a.redirectAllowed(origin.URL, prev.URL, req.URL),
+	}
+
+	if evnt.Allowed {
+		evnt.Err = a.decorate(req, proxy)
+	} else {
+		for _, h := range credentialHeaders {
+			req.Header.Del(h)
+		}
+		// Credentials copied from the original request by the client.
+		decorators, _, _ := a.decoratorsFor(origin)
+		for _, d := range decorators {
+			if s, ok := d.(stripper); ok {
+				s.Strip(req)
+			}
+		}
+		// The egress proxy is the same wherever the request is redirected to.
+		if _, proxies, err := a.decoratorsFor(req); err == nil {
+			evnt.Err = a.decorateProxy(req, proxies, proxy)
+		}
+	}
+
+	a.dispatch(evnt)
+	return evnt.Allowed, evnt.Err
+}
+
+// redact returns the URL as a string without any credentials the decorators
+// may have placed in it.
+func (a *Vouch) redact(u *url.URL) string {
+	for _, r := range a.redactors {
+		u = r.Redact(u)
+	}
+	return u.Redacted()
+}
+
+// redirectAllowed reports whether credentials may follow a redirect from prev
+// to to, for a request originally sent to origin.
+func (a *Vouch) redirectAllowed(origin, prev, to *url.URL) bool {
+	if strings.EqualFold(prev.Scheme, "https") && !strings.EqualFold(to.Scheme, "https") {
+		return false
+	}
+
+	if strings.EqualFold(to.Hostname(), origin.Hostname()) {
+		return true
+	}
+
+	return hostListed(a.redirectHosts, to)
+}
+
+// hostListed reports whether the host of the URL, with or without its port,
+// is in the list of normalized hosts.
+func hostListed(hosts []string, u *url.URL) bool {
+	hostname := strings.ToLower(u.Hostname())
+	hostport := net.JoinHostPort(hostname, port(u))
+	for _, host := range hosts {
+		if host == hostname || host == hostport {
+			return true
+		}
+	}
+	return false
+}
+
+// port returns the port of the URL, or the default port of its scheme.
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if strings.EqualFold(u.Scheme, "https") {
+		return "443"
+	}
+	return "80"
+}
+
+// origin returns the first request of a chain of redirects.
+func origin(req *http.Request) *http.Request {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req
+}
@@ -0,0 +1,329 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xmidt-org/vouch/apikey"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/httpsig"
+)
+
+func TestVouch_CheckRedirect(t *testing.T) {
+	tests := []struct {
+		description   string
+		redirectHosts []string
+		via           []string
+		to            string
+		expectAuth    string
+		expectAllowed bool
+		expectError   bool
+	}{
+		{
+			description:   "Same host",
+			via:           []string{"https://example.com/a"},
+			to:            "https://example.com/b",
+			expectAuth:    "Bearer token",
+			expectAllowed: true,
+		},
+		{
+			description:   "Same host on a different port",
+			via:           []string{"https://example.com/a"},
+			to:            "https://example.com:8443/b",
+			expectAuth:    "Bearer token",
+			expectAllowed: true,
+		},
+		{
+			description: "Different host",
+			via:         []string{"https://example.com/a"},
+			to:          "https://attacker.example.net/b",
+		},
+		{
+			description: "Back to a different host after a redirect",
+			via:         []string{"https://example.com/a", "https://example.com/b"},
+			to:          "https://other.example.com/c",
+		},
+		{
+			description:   "Allowed host",
+			redirectHosts: []string{"CDN.example.com"},
+			via:           []string{"https://example.com/a"},
+			to:            "https://cdn.example.com/b",
+			expectAuth:    "Bearer token",
+			expectAllowed: true,
+		},
+		{
+			description:   "Allowed host and port",
+			redirectHosts: []string{"cdn.example.com:443"},
+			via:           []string{"https://example.com/a"},
+			to:            "https://cdn.example.com/b",
+			expectAuth:    "Bearer token",
+			expectAllowed: true,
+		},
+		{
+			description:   "Allowed host on another port",
+			redirectHosts: []string{"cdn.example.com:8443"},
+			via:           []string{"https://example.com/a"},
+			to:            "https://cdn.example.com/b",
+		},
+		{
+			description: "Downgrade to http",
+			via:         []string{"https://example.com/a"},
+			to:          "http://example.com/b",
+		},
+		{
+			description: "Too many redirects",
+			via: []string{
+				"https://example.com/1", "https://example.com/2",
+				"https://example.com/3", "https://example.com/4",
+				"https://example.com/5", "https://example.com/6",
+				"https://example.com/7", "https://example.com/8",
+				"https://example.com/9", "https://example.com/10",
+			},
+			to:          "https://example.com/b",
+			expectAuth:  "Bearer stale",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var got []events.RedirectEvent
+			v := &Vouch{
+				decorators: []decorator{
+					mockDecorator{
+						decorateFunc: func(req *http.Request) error {
+							req.Header.Set("Authorization", "Bearer token")
+							return nil
+						},
+					},
+				},
+			}
+			handleRedirectHosts(tc.redirectHosts).apply(v)
+			v.AddRedirectEventListener(events.RedirectEventListenerFunc(func(e events.RedirectEvent) {
+				got = append(got, e)
+			}))
+
+			via := make([]*http.Request, 0, len(tc.via))
+			for _, u := range tc.via {
+				r, _ := http.NewRequest("GET", u, nil)
+				via = append(via, r)
+			}
+			req, _ := http.NewRequest("GET", tc.to, nil)
+			req.Header.Set("Authorization", "Bearer stale")
+
+			err := v.CheckRedirect(req, via)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+			} else if err != nil {
+				t.Errorf("did not expect an error but got: %v", err)
+			}
+
+			if auth := req.Header.Get("Authorization"); auth != tc.expectAuth {
+				t.Errorf("expected Authorization %q but got %q", tc.expectAuth, auth)
+			}
+
+			if tc.expectError {
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("expected 1 redirect event but got %d", len(got))
+			}
+			if got[0].Allowed != tc.expectAllowed {
+				t.Errorf("expected Allowed %t but got %t", tc.expectAllowed, got[0].Allowed)
+			}
+			if got[0].From != tc.via[len(tc.via)-1] || got[0].To != tc.to {
+				t.Errorf("unexpected redirect event: %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTripRedirect(t *testing.T) {
+	var gotAuth []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+
+	// The other server is reached via localhost so it is a different host.
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/done", http.StatusFound)
+		case "/other":
+			http.Redirect(w, r, otherURL+"/done", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		description string
+		path        string
+		expectAuth  []string
+	}{
+		{
+			description: "Redirect to the same host",
+			path:        "/same",
+			expectAuth:  []string{"Bearer token", "Bearer token"},
+		},
+		{
+			description: "Redirect to another host",
+			path:        "/other",
+			expectAuth:  []string{"Bearer token", ""},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			gotAuth = nil
+			var allowed []bool
+			v := &Vouch{
+				insecureHosts: []string{"127.0.0.1", "localhost"},
+				decorators: []decorator{
+					mockDecorator{
+						decorateFunc: func(req *http.Request) error {
+							req.Header.Set("Authorization", "Bearer token")
+							return nil
+						},
+					},
+				},
+			}
+			v.AddRedirectEventListener(events.RedirectEventListenerFunc(func(e events.RedirectEvent) {
+				allowed = append(allowed, e.Allowed)
+			}))
+
+			resp, err := v.Client(nil).Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			resp.Body.Close()
+
+			if strings.Join(gotAuth, ",") != strings.Join(tc.expectAuth, ",") {
+				t.Errorf("expected Authorization headers %q but got %q", tc.expectAuth, gotAuth)
+			}
+			if len(allowed) != 1 || allowed[0] != (tc.expectAuth[1] != "") {
+				t.Errorf("unexpected redirect decisions: %v", allowed)
+			}
+		})
+	}
+}
+
+func TestVouch_CheckRedirectAPIKey(t *testing.T) {
+	tests := []struct {
+		description string
+		config      apikey.Config
+		to          string
+		expectTo    string
+		expectFrom  string
+	}{
+		{
+			description: "API key header",
+			config:      apikey.Config{Key: "secret"},
+			to:          "https://other.example.com/b",
+			expectFrom:  "https://example.com/a",
+			expectTo:    "https://other.example.com/b",
+		},
+		{
+			description: "API key query parameter",
+			config:      apikey.Config{Key: "secret", In: apikey.InQuery},
+			to:          "https://other.example.com/b?api_key=secret",
+			expectFrom:  "https://example.com/a?api_key=REDACTED",
+			expectTo:    "https://other.example.com/b?api_key=REDACTED",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var got []events.RedirectEvent
+			v, err := New(Config{APIKey: tc.config},
+				WithRedirectEventListener(events.RedirectEventListenerFunc(func(e events.RedirectEvent) {
+					got = append(got, e)
+				})),
+			)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			orig, _ := http.NewRequest("GET", "https://example.com/a", nil)
+			if err := v.Decorate(orig); err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			// The client copies the headers of the original request.
+			req, _ := http.NewRequest("GET", tc.to, nil)
+			req.Header = orig.Header.Clone()
+
+			if err := v.CheckRedirect(req, []*http.Request{orig}); err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			if strings.Contains(req.URL.String(), "secret") || req.Header.Get("X-Api-Key") != "" {
+				t.Errorf("expected the API key to be removed: %s %v", req.URL, req.Header)
+			}
+			if len(got) != 1 {
+				t.Fatalf("expected 1 redirect event but got %d", len(got))
+			}
+			if got[0].From != tc.expectFrom || got[0].To != tc.expectTo {
+				t.Errorf("unexpected redirect event: %+v", got[0])
+			}
+		})
+	}
+}
+
+func TestVouch_CheckRedirectHTTPSig(t *testing.T) {
+	tests := []struct {
+		description string
+		to          string
+		expectKept  bool
+	}{
+		{
+			description: "Same host keeps the signature",
+			to:          "https://example.com/b",
+			expectKept:  true,
+		},
+		{
+			description: "Other host drops the signature",
+			to:          "https://other.example.com/b",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			v, err := New(Config{
+				HTTPSig: httpsig.Config{KeyID: "key", Secret: "secret"},
+			})
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			orig, _ := http.NewRequest("POST", "https://example.com/a", strings.NewReader("body"))
+			if err := v.Decorate(orig); err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			// The client copies the headers of the original request.
+			req, _ := http.NewRequest("POST", tc.to, strings.NewReader("body"))
+			req.Header = orig.Header.Clone()
+
+			if err := v.CheckRedirect(req, []*http.Request{orig}); err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			for _, h := range []string{"Signature", "Signature-Input", "Content-Digest"} {
+				if kept := req.Header.Get(h) != ""; kept != tc.expectKept {
+					t.Errorf("expected %s kept to be %t but got %t", h, tc.expectKept, kept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/xmidt-org/vouch/apikey"
+	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/bearer"
+	"github.com/xmidt-org/vouch/digest"
+	"github.com/xmidt-org/vouch/httpsig"
+	"github.com/xmidt-org/vouch/oauth"
+	"github.com/xmidt-org/vouch/serviceaccount"
+	"github.com/xmidt-org/vouch/sigv4"
+)
+
+// ErrNoRoute is returned when routes are configured, none of them match the
+// request and there are no default credentials.
+var ErrNoRoute = errors.New("no route matches the request")
+
+// Route applies its own credentials to requests sent to a matching
+// destination. Empty match fields match any value.
+type Route struct {
+	// Scheme matches the scheme of the request URL, such as "https".
+	Scheme string
+
+	// Host matches the host name of the request URL, without the port.
+	Host string
+
+	// Port matches the port of the request URL. The default port of the
+	// scheme is used when the URL does not specify one.
+	Port string
+
+	// PathPrefix matches the beginning of the path of the request URL, on a
+	// segment boundary: "/api" matches "/api" and "/api/things", but not
+	// "/apiv2".
+	PathPrefix string
+
+	// Basic is the configuration for basic authentication.
+	Basic basic.Config
+
+	// OAuth is the configuration for OAuth authentication.
+	OAuth oauth.Config
+
+	// Bearer is the configuration for static bearer token authentication.
+	Bearer bearer.Config
+
+	// APIKey is the configuration for API key authentication.
+	APIKey apikey.Config
+
+	// SigV4 is the configuration for AWS Signature Version 4 signing.
+	SigV4 sigv4.Config
+
+	// HTTPSig is the configuration for HTTP Message Signatures.
+	HTTPSig httpsig.Config
+
+	// Digest is the configuration for Digest authentication.
+	Digest digest.Config
+
+	// ServiceAccount is the configuration for sending a Kubernetes projected
+	// service account token.
+	ServiceAccount serviceaccount.Config
+}
+
+// decorators creates the priority ordered decorators of the route, split
+// between the credentials for the destination and for an egress proxy.
+func (r Route) decorators(dispatch func(any)) ([]decorator, []decorator, error) {
+	var decorators []decorator
+
+	if b := basic.New(r.Basic, dispatch); b != nil {
+		decorators = append(decorators, b)
+	}
+
+	o, err := oauth.New(r.OAuth, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o != nil {
+		decorators = append(decorators, o)
+	}
+
+	b, err := bearer.New(r.Bearer, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b != nil {
+		decorators = append(decorators, b)
+	}
+
+	k, err := apikey.New(r.APIKey, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k != nil {
+		decorators = append(decorators, k)
+	}
+
+	s, err := sigv4.New(r.SigV4, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s != nil {
+		decorators = append(decorators, s)
+	}
+
+	h, err := httpsig.New(r.HTTPSig, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if h != nil {
+		decorators = append(decorators, h)
+	}
+
+	if d := digest.New(r.Digest, dispatch); d != nil {
+		decorators = append(decorators, d)
+	}
+
+	sa, err := serviceaccount.New(r.ServiceAccount, dispatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sa != nil {
+		decorators = append(decorators, sa)
+	}
+
+	decorators, proxies := splitDecorators(decorators)
+	return decorators, proxies, nil
+}
+
+// route is a Route ready to match requests.
+type route struct {
+	scheme     string
+	host       string
+	port       string
+	pathPrefix string
+	decorators []decorator
+	proxies    []decorator
+}
+
+func (r *route) matches(u *url.URL) bool {
+	switch {
+	case r.scheme != "" && r.scheme != strings.ToLower(u.Scheme):
+	case r.host != "" && r.host != strings.ToLower(u.Hostname()):
+	case r.port != "" && r.port != port(u):
+	case !hasPathPrefix(u.Path, r.pathPrefix):
+	default:
+		return true
+	}
+	return false
+}
+
+// hasPathPrefix reports whether path is within the prefix, on a segment
+// boundary.
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// decoratorsFor returns the decorators to use for the request, and those
+// for an egress proxy. A route without proxy credentials of its own uses
+// the default ones, since the proxy does not depend on the destination.
+func (a *Vouch) decoratorsFor(req *http.Request) ([]decorator, []decorator, error) {
+	if len(a.routes) == 0 {
+		return a.decorators, a.proxies, nil
+	}
+
+	for i := range a.routes {
+		if a.routes[i].matches(req.URL) {
+			proxies := a.routes[i].proxies
+			if len(proxies) == 0 {
+				proxies = a.proxies
+			}
+			return a.routes[i].decorators, proxies, nil
+		}
+	}
+
+	if len(a.decorators) > 0 || len(a.proxies) > 0 {
+		return a.decorators, a.proxies, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", ErrNoRoute, destination(req.URL))
+}
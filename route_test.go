@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/xmidt-org/vouch/basic"
+	"github.com/xmidt-org/vouch/oauth"
+)
+
+func TestVouch_DecorateRoutes(t *testing.T) {
+	routes := []Route{
+		{
+			Host:       "api.example.com",
+			PathPrefix: "/v2/",
+			Basic:      basic.Config{Username: "v2", Password: "pass"},
+		},
+		{
+			Host:       "api.example.com",
+			PathPrefix: "/api",
+			Basic:      basic.Config{Username: "prefix", Password: "pass"},
+		},
+		{
+			Host:  "API.example.com",
+			Basic: basic.Config{Username: "api", Password: "pass"},
+		},
+		{
+			Scheme: "http",
+			Port:   "8080",
+			Basic:  basic.Config{Username: "plain", Password: "pass"},
+		},
+	}
+
+	tests := []struct {
+		description string
+		config      Config
+		url         string
+		expectUser  string
+		expectError error
+	}{
+		{
+			description: "Host and path prefix",
+			config:      Config{Routes: routes},
+			url:         "https://api.example.com/v2/things",
+			expectUser:  "v2",
+		},
+		{
+			description: "Path equal to the prefix",
+			config:      Config{Routes: routes},
+			url:         "https://api.example.com/api",
+			expectUser:  "prefix",
+		},
+		{
+			description: "Path below the prefix",
+			config:      Config{Routes: routes},
+			url:         "https://api.example.com/api/things",
+			expectUser:  "prefix",
+		},
+		{
+			description: "Path continuing the prefix within a segment",
+			config:      Config{Routes: routes},
+			url:         "https://api.example.com/apiv2",
+			expectUser:  "api",
+		},
+		{
+			description: "Host only",
+			config:      Config{Routes: routes},
+			url:         "https://api.example.com:443/v1/things",
+			expectUser:  "api",
+		},
+		{
+			description: "Scheme and port",
+			config: Config{
+				Routes:        routes,
+				InsecureHosts: []string{"other.example.com:8080"},
+			},
+			url:        "http://other.example.com:8080/",
+			expectUser: "plain",
+		},
+		{
+			description: "Default port of the scheme",
+			config: Config{
+				Routes: []Route{
+					{
+						Port:  "443",
+						Basic: basic.Config{Username: "tls", Password: "pass"},
+					},
+				},
+			},
+			url:        "https://other.example.com/",
+			expectUser: "tls",
+		},
+		{
+			description: "No match falls back to the default credentials",
+			config: Config{
+				Basic:  basic.Config{Username: "default", Password: "pass"},
+				Routes: routes,
+			},
+			url:        "https://other.example.com/",
+			expectUser: "default",
+		},
+		{
+			description: "No match without default credentials",
+			config:      Config{Routes: routes},
+			url:         "https://other.example.com/",
+			expectError: ErrNoRoute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			v, err := New(tc.config)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			err = v.Decorate(req)
+			if !errors.Is(err, tc.expectError) {
+				t.Fatalf("expected error %v but got: %v", tc.expectError, err)
+			}
+
+			user, _, _ := req.BasicAuth()
+			if user != tc.expectUser {
+				t.Errorf("expected user %q but got %q", tc.expectUser, user)
+			}
+		})
+	}
+}
+
+func TestVouch_NewRoutes(t *testing.T) {
+	_, err := New(Config{
+		Routes: []Route{
+			{
+				Host: "api.example.com",
+				OAuth: oauth.Config{
+					ClientID:  "test-client",
+					TokenURL:  "https://example.com/token",
+					AuthStyle: "invalid",
+				},
+			},
+		},
+	})
+	if err == nil {
+		t.Errorf("expected an error but got none")
+	}
+}
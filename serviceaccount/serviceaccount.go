@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package serviceaccount sends the Kubernetes service account token that the
+// kubelet projects into a pod, following its rotations.
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/filestamp"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+const (
+	SERVICE_ACCOUNT_TYPE = "serviceaccount"
+)
+
+// refreshWindow is how long before its exp claim the token file is read on
+// every use, until the kubelet has rotated it.
+const refreshWindow = time.Minute
+
+var (
+	errEmptyToken   = errors.New("service account token is empty")
+	errExpiredToken = errors.New("service account token has expired")
+)
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 550.
+	Priority int
+
+	// TokenFile is the path of the projected service account token, such
+	// as "/var/run/secrets/tokens/vault-token". The file is read again when
+	// it changes and as the token nears its exp claim.
+	TokenFile string
+}
+
+func (c *Config) IsActive() bool {
+	return c.TokenFile != ""
+}
+
+// ServiceAccount sends the token of a projected service account token
+// volume as a bearer token.
+type ServiceAccount struct {
+	path     string
+	priority int
+	dispatch func(any)
+	now      func() time.Time
+
+	mu     sync.Mutex
+	stamp  filestamp.Stamp
+	token  string
+	expiry time.Time
+}
+
+func (s *ServiceAccount) Priority() int {
+	return s.priority
+}
+
+// New creates a decorator that sends the projected service account token.
+// The token file must be readable. If the configuration is not active, New
+// returns nil.
+func New(config Config, dispatch func(any)) (*ServiceAccount, error) {
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 550
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	s := ServiceAccount{
+		path:     config.TokenFile,
+		priority: priority,
+		dispatch: dispatch,
+		now:      time.Now,
+	}
+	if _, _, err := s.current(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Token returns the current token. It can provide the subject token of an
+// OAuth token exchange; see oauth.Exchange.
+func (s *ServiceAccount) Token(context.Context) (string, error) {
+	token, _, err := s.current()
+	return token, err
+}
+
+// Decorate sets the Authorization header on an outgoing request.
+func (s *ServiceAccount) Decorate(req *http.Request) error {
+	h, err := s.Headers(req.Context())
+	if err != nil {
+		return err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	return nil
+}
+
+// Headers returns the headers that Decorate sets on a request, for protocols
+// that do not send an *http.Request.
+func (s *ServiceAccount) Headers(context.Context) (http.Header, error) {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: SERVICE_ACCOUNT_TYPE,
+	}
+
+	token, expiry, err := s.current()
+	evnt.Duration = time.Since(evnt.At)
+	if err != nil {
+		evnt.Err = err
+		s.dispatch(evnt)
+		return nil, err
+	}
+	evnt.Expiration = expiry
+
+	s.dispatch(evnt)
+	return http.Header{
+		"Authorization": {"Bearer " + token},
+	}, nil
+}
+
+// current returns the token and its expiry, reading the file again when it
+// has changed or the token is about to expire. A FetchEvent is dispatched
+// when a new token is read or the file cannot be read.
+func (s *ServiceAccount) current() (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	stamp, err := filestamp.Stat(s.path)
+	if err == nil && s.token != "" && stamp.Equal(s.stamp) &&
+		(s.expiry.IsZero() || now.Before(s.expiry.Add(-refreshWindow))) {
+		return s.token, s.expiry, nil
+	}
+
+	evnt := events.FetchEvent{
+		At:   time.Now(),
+		Type: SERVICE_ACCOUNT_TYPE,
+	}
+
+	var token string
+	var expiry time.Time
+	if err == nil {
+		token, expiry, err = read(s.path)
+	} else {
+		err = fmt.Errorf("reading TokenFile: %w", err)
+	}
+	evnt.Duration = time.Since(evnt.At)
+	if err == nil && !expiry.IsZero() && !now.Before(expiry) {
+		err = errExpiredToken
+	}
+	if err != nil {
+		evnt.Err = err
+		s.dispatch(evnt)
+		return "", time.Time{}, err
+	}
+
+	if token != s.token {
+		evnt.Expiration = expiry
+		evnt.OriginalExpiration = expiry
+		s.dispatch(evnt)
+	}
+
+	s.token, s.expiry, s.stamp = token, expiry, stamp
+	return token, expiry, nil
+}
+
+// read returns the token in the file and the time of its exp claim, which
+// is zero for tokens that are not JWTs or have no exp claim.
+func read(path string) (string, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("reading TokenFile: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", time.Time{}, errEmptyToken
+	}
+
+	expiry, err := jwt.Expiry(token)
+	if err != nil {
+		expiry = time.Time{}
+	}
+	return token, expiry, nil
+}
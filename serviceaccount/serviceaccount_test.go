@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package serviceaccount
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/vouch/events"
+	"github.com/xmidt-org/vouch/internal/jwt"
+)
+
+func newToken(t *testing.T, sub string, exp time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tok, err := jwt.Sign(key, nil, map[string]any{"sub": sub, "aud": "vault", "exp": exp.Unix()})
+	require.NoError(t, err)
+	return tok
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		return path
+	}
+
+	tests := []struct {
+		description    string
+		config         Config
+		expectPriority int
+		expectNil      bool
+		expectError    bool
+	}{
+		{
+			description: "empty configuration means no auth",
+			expectNil:   true,
+		}, {
+			description:    "a projected token",
+			config:         Config{TokenFile: write("token", newToken(t, "pod", time.Now().Add(time.Hour))+"\n")},
+			expectPriority: 550,
+		}, {
+			description:    "an opaque token with a priority",
+			config:         Config{Priority: 12, TokenFile: write("opaque", "opaque")},
+			expectPriority: 12,
+		}, {
+			description: "a missing token file",
+			config:      Config{TokenFile: filepath.Join(dir, "missing")},
+			expectError: true,
+		}, {
+			description: "an empty token file",
+			config:      Config{TokenFile: write("empty", "\n")},
+			expectError: true,
+		}, {
+			description: "an expired token",
+			config:      Config{TokenFile: write("expired", newToken(t, "pod", time.Now().Add(-time.Minute)))},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			s, err := New(tc.config, nil)
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, s)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectNil {
+				assert.Nil(t, s)
+				return
+			}
+			require.NotNil(t, s)
+			assert.Equal(t, tc.expectPriority, s.Priority())
+		})
+	}
+}
+
+func TestServiceAccountRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	now := time.Now()
+	first := newToken(t, "first", now.Add(10*time.Minute))
+	require.NoError(t, os.WriteFile(path, []byte(first), 0600))
+
+	var fetches []events.FetchEvent
+	var decorates []events.DecorateEvent
+	s, err := New(Config{TokenFile: path}, func(evnt any) {
+		switch e := evnt.(type) {
+		case events.FetchEvent:
+			fetches = append(fetches, e)
+		case events.DecorateEvent:
+			decorates = append(decorates, e)
+		}
+	})
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+
+	decorate := func() (string, error) {
+		req, _ := http.NewRequest("GET", "https://example.com", nil)
+		err := s.Decorate(req)
+		return req.Header.Get("Authorization"), err
+	}
+
+	auth, err := decorate()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+first, auth)
+	require.Len(t, fetches, 1)
+	assert.Equal(t, SERVICE_ACCOUNT_TYPE, fetches[0].Type)
+	assert.Equal(t, now.Add(10*time.Minute).Unix(), fetches[0].Expiration.Unix())
+	require.Len(t, decorates, 1)
+	assert.Equal(t, fetches[0].Expiration, decorates[0].Expiration)
+
+	// The kubelet rotates the token.
+	second := newToken(t, "second", now.Add(20*time.Minute))
+	require.NoError(t, os.WriteFile(path, []byte(second), 0600))
+	later := now.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	for range 2 {
+		auth, err = decorate()
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer "+second, auth)
+	}
+	assert.Len(t, fetches, 2)
+
+	// A rewrite that keeps the modification time and size is only noticed
+	// as the token nears its expiry.
+	third := newToken(t, "third-", now.Add(40*time.Minute))
+	require.Len(t, third, len(second))
+	require.NoError(t, os.WriteFile(path, []byte(third), 0600))
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	auth, err = decorate()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+second, auth)
+
+	s.now = func() time.Time { return now.Add(20*time.Minute - refreshWindow/2) }
+	auth, err = decorate()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+third, auth)
+	assert.Len(t, fetches, 3)
+
+	token, err := s.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, third, token)
+
+	// A token the kubelet failed to rotate is not sent once it has expired.
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = decorate()
+	assert.ErrorIs(t, err, errExpiredToken)
+	require.Len(t, fetches, 4)
+	assert.ErrorIs(t, fetches[3].Err, errExpiredToken)
+	assert.ErrorIs(t, decorates[len(decorates)-1].Err, errExpiredToken)
+
+	// The token file is gone.
+	s.now = func() time.Time { return now }
+	require.NoError(t, os.Remove(path))
+	_, err = s.Headers(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package sigv4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/xmidt-org/vouch/internal/filestamp"
+)
+
+var errNoCredentials = errors.New("credentials have no access key ID or secret access key")
+
+// Credentials are the AWS credentials used to sign requests.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+}
+
+// CredentialsProvider supplies the credentials for each request, so they can
+// be rotated.
+type CredentialsProvider interface {
+	Credentials(context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc is a function that implements CredentialsProvider.
+type CredentialsProviderFunc func(context.Context) (Credentials, error)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// staticCredentials always provides the same credentials.
+type staticCredentials Credentials
+
+func (s staticCredentials) Credentials(context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// fileCredentials reads the credentials from a JSON file in the format
+// written by a credential_process, reading it again whenever it changes.
+type fileCredentials struct {
+	path string
+
+	mu    sync.Mutex
+	stamp filestamp.Stamp
+	creds Credentials
+}
+
+// processCredentials is the credential_process output format.
+type processCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+}
+
+func (f *fileCredentials) Credentials(context.Context) (Credentials, error) {
+	stamp, err := filestamp.Stat(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.creds.AccessKeyID != "" && stamp.Equal(f.stamp) {
+		return f.creds, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var pc processCredentials
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return Credentials{}, fmt.Errorf("invalid CredentialsFile %s: %w", f.path, err)
+	}
+
+	creds := Credentials(pc)
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("invalid CredentialsFile %s: %w", f.path, errNoCredentials)
+	}
+
+	f.creds, f.stamp = creds, stamp
+	return creds, nil
+}
@@ -0,0 +1,409 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sigv4 signs requests with AWS Signature Version 4.
+package sigv4
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/vouch/events"
+)
+
+const (
+	SIGV4_TYPE = "sigv4"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	timeFormat      = "20060102T150405Z"
+	dateFormat      = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	headerDate          = "X-Amz-Date"
+	headerSecurityToken = "X-Amz-Security-Token"
+	headerContentSHA256 = "X-Amz-Content-Sha256"
+)
+
+var (
+	errNoRegion     = errors.New("sigv4 Region is required")
+	errNoService    = errors.New("sigv4 Service is required")
+	errBodyNotReset = errors.New("sigv4 cannot hash a request body without GetBody; set UnsignedPayload")
+)
+
+// unsignedHeaders are not signed since they are commonly changed or removed
+// on the way to the server.
+var unsignedHeaders = map[string]bool{
+	"authorization":       true,
+	"connection":          true,
+	"expect":              true,
+	"proxy-authorization": true,
+	"transfer-encoding":   true,
+	"user-agent":          true,
+	"x-amzn-trace-id":     true,
+}
+
+type Config struct {
+	// Priority is the priority of this config relative to others.
+	// Higher numbers are higher priority. 0 means default, which is 600.
+	Priority int
+
+	// Region is the AWS region of the service, such as "us-east-1".
+	Region string
+
+	// Service is the signing name of the service, such as "s3" or
+	// "execute-api".
+	Service string
+
+	// AccessKeyID is the access key ID of static credentials.
+	AccessKeyID string
+
+	// SecretAccessKey is the secret access key of static credentials.
+	SecretAccessKey string
+
+	// SessionToken is the session token of static temporary credentials.
+	SessionToken string
+
+	// CredentialsFile is the path of a JSON file holding the credentials in
+	// the format written by a credential_process. It is read again whenever
+	// it changes, so the credentials can be rotated.
+	CredentialsFile string
+
+	// Provider supplies the credentials for each request. It takes
+	// precedence over the static credentials and CredentialsFile.
+	Provider CredentialsProvider
+
+	// UnsignedPayload sends the body without hashing it. Otherwise the body
+	// of a request is read using Request.GetBody to hash it.
+	UnsignedPayload bool
+}
+
+func (c *Config) IsActive() bool {
+	return c.Provider != nil || c.CredentialsFile != "" || c.AccessKeyID != ""
+}
+
+type SigV4 struct {
+	priority int
+	region   string
+	service  string
+	provider CredentialsProvider
+	unsigned bool
+	now      func() time.Time
+	dispatch func(any)
+
+	// normalize and escapeTwice are how the canonical path is built; both
+	// are set for every service but S3.
+	normalize   bool
+	escapeTwice bool
+}
+
+func (s *SigV4) Priority() int {
+	return s.priority
+}
+
+// New creates a decorator that signs requests. If the configuration is not
+// active, New returns nil.
+func New(config Config, dispatch func(any)) (*SigV4, error) {
+	if !config.IsActive() {
+		return nil, nil
+	}
+
+	if config.Region == "" {
+		return nil, errNoRegion
+	}
+	if config.Service == "" {
+		return nil, errNoService
+	}
+
+	provider := config.Provider
+	switch {
+	case provider != nil:
+	case config.CredentialsFile != "":
+		f := &fileCredentials{path: config.CredentialsFile}
+		if _, err := f.Credentials(context.Background()); err != nil {
+			return nil, err
+		}
+		provider = f
+	default:
+		if config.SecretAccessKey == "" {
+			return nil, errNoCredentials
+		}
+		provider = staticCredentials{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+		}
+	}
+
+	priority := config.Priority
+	if priority == 0 {
+		priority = 600
+	}
+	if dispatch == nil {
+		dispatch = func(any) {}
+	}
+
+	return &SigV4{
+		priority: priority,
+		region:   config.Region,
+		service:  config.Service,
+		provider: provider,
+		unsigned: config.UnsignedPayload,
+		now:      time.Now,
+		dispatch: dispatch,
+
+		normalize:   config.Service != "s3",
+		escapeTwice: config.Service != "s3",
+	}, nil
+}
+
+// Decorate signs the request, setting the Authorization and X-Amz-Date
+// headers. The request's context bounds how long Decorate waits for the
+// credentials.
+func (s *SigV4) Decorate(req *http.Request) error {
+	evnt := events.DecorateEvent{
+		At:   time.Now(),
+		Type: SIGV4_TYPE,
+	}
+
+	err := s.sign(req)
+	evnt.Duration = time.Since(evnt.At)
+	evnt.Err = err
+	s.dispatch(evnt)
+	return err
+}
+
+// Strip removes the headers set by Decorate other than Authorization, for a
+// request that is redirected to another host.
+func (s *SigV4) Strip(req *http.Request) {
+	req.Header.Del(headerDate)
+	req.Header.Del(headerSecurityToken)
+	req.Header.Del(headerContentSHA256)
+}
+
+func (s *SigV4) sign(req *http.Request) error {
+	creds, err := s.provider.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return errNoCredentials
+	}
+
+	payload, err := s.payloadHash(req)
+	if err != nil {
+		return err
+	}
+
+	// Headers left by an earlier decoration are replaced.
+	req.Header.Del("Authorization")
+	s.Strip(req)
+
+	now := s.now().UTC()
+	req.Header.Set(headerDate, now.Format(timeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set(headerSecurityToken, creds.SessionToken)
+	}
+	if s.service == "s3" || s.unsigned {
+		req.Header.Set(headerContentSHA256, payload)
+	}
+
+	canonical, signed := s.canonicalRequest(req, payload)
+
+	scope := strings.Join([]string{now.Format(dateFormat), s.region, s.service, "aws4_request"}, "/")
+	toSign := strings.Join([]string{algorithm, now.Format(timeFormat), scope, hashHex([]byte(canonical))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(dateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, creds.AccessKeyID, scope, signed, signature))
+	return nil
+}
+
+// payloadHash returns the hex encoded SHA-256 hash of the request body.
+func (s *SigV4) payloadHash(req *http.Request) (string, error) {
+	if s.unsigned {
+		return unsignedPayload, nil
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return hashHex(nil), nil
+	}
+	if req.GetBody == nil {
+		return "", errBodyNotReset
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalRequest returns the canonical form of the request and the list of
+// signed headers.
+func (s *SigV4) canonicalRequest(req *http.Request, payload string) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string][]string{
+		"host": {host},
+	}
+	for k, v := range req.Header {
+		name := strings.ToLower(k)
+		if unsignedHeaders[name] {
+			continue
+		}
+		values[name] = append(values[name], v...)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		vals := make([]string, len(values[name]))
+		for i, v := range values[name] {
+			vals[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers.WriteString(name + ":" + strings.Join(vals, ",") + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	return strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		headers.String(),
+		signed,
+		payload,
+	}, "\n"), signed
+}
+
+// canonicalPath encodes each segment of the path. Every service but S3
+// has the path normalized and its segments encoded twice; S3 object keys may
+// contain any segment and are encoded once. The segments are split on the
+// escaped path so an encoded "/" stays within its segment.
+func (s *SigV4) canonicalPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+
+	segments := strings.Split(p, "/")
+	if s.normalize {
+		segments = normalize(segments)
+	}
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		seg = escape(seg)
+		if s.escapeTwice {
+			seg = escape(seg)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, "/")
+}
+
+// normalize removes the empty, "." and ".." segments of an absolute path,
+// keeping a trailing slash.
+func normalize(segments []string) []string {
+	out := []string{""}
+	for _, seg := range segments[1:] {
+		switch seg {
+		case "", ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+
+	last := segments[len(segments)-1]
+	if last == "" || last == "." || last == ".." || len(out) == 1 {
+		out = append(out, "")
+	}
+	return out
+}
+
+// canonicalQuery returns the query parameters sorted by name and value.
+func canonicalQuery(u *url.URL) string {
+	var params [][2]string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		k, _ = url.QueryUnescape(k)
+		v, _ = url.QueryUnescape(v)
+		params = append(params, [2]string{escape(k), escape(v)})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, len(params))
+	for i, p := range params {
+		encoded[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+// escape percent encodes everything but the unreserved characters of
+// RFC 3986.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
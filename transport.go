@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// maxDrain is the most of a rejected response body that is read so the
+// connection can be reused for the retry.
+const maxDrain = 4 << 10
+
+var errNilVouch = errors.New("vouch: Transport's Vouch is nil")
+
+// Transport is an http.RoundTripper that decorates every outgoing request
+// using a Vouch before handing it to the Base RoundTripper.
+//
+// The request passed to RoundTrip is never modified; a clone is decorated
+// and sent instead, as required by the http.RoundTripper contract.
+//
+// When the server answers 401 with a challenge one of the decorators can
+// handle, such as a Bearer challenge for a revoked OAuth token, the request is
+// decorated again and replayed once. Requests with a body are only replayed
+// if Request.GetBody is set. Responses that are not retried are offered to
+// the decorators, so they can keep state such as the DPoP nonce of a server.
+//
+// Requests created by following a redirect are only decorated when the
+// redirect policy of the Vouch allows it; see Vouch.CheckRedirect.
+type Transport struct {
+	// Vouch decorates the outgoing requests.
+	Vouch *Vouch
+
+	// Base is the base RoundTripper used to make HTTP requests.
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// RoundTrip decorates a clone of the request and sends it using the Base
+// RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBodyClosed := false
+	if req.Body != nil {
+		defer func() {
+			if !reqBodyClosed {
+				req.Body.Close()
+			}
+		}()
+	}
+
+	if t.Vouch == nil {
+		return nil, errNilVouch
+	}
+
+	req2 := req.Clone(req.Context())
+	decorated, err := t.decorate(req2)
+	if err != nil {
+		return nil, err
+	}
+
+	// req.Body is assumed to be closed by the base RoundTripper.
+	reqBodyClosed = true
+	resp, err := t.base().RoundTrip(req2)
+	if err != nil || !decorated {
+		return resp, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || !t.Vouch.challenge(req2, resp) {
+		t.Vouch.observe(req2, resp)
+		return resp, nil
+	}
+
+	// The original response is returned if the request cannot be replayed.
+	req3, ok := rewind(req)
+	if !ok {
+		return resp, nil
+	}
+	if t.Vouch.decorate(req3, t.proxy()) != nil {
+		if req3.Body != nil {
+			req3.Body.Close()
+		}
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+
+	resp, err = t.base().RoundTrip(req3)
+	if err == nil {
+		t.Vouch.observe(req3, resp)
+	}
+	return resp, err
+}
+
+// decorate applies the credentials to the request, following the redirect
+// policy of the Vouch for requests created by a redirect. It reports whether
+// the credentials were applied.
+func (t *Transport) decorate(req *http.Request) (bool, error) {
+	if req.Response == nil || req.Response.Request == nil {
+		return true, t.Vouch.decorate(req, t.proxy())
+	}
+	return t.Vouch.redirect(req, origin(req), req.Response.Request, t.proxy())
+}
+
+// proxy returns how the Base RoundTripper finds the egress proxy of a
+// request. RoundTrippers other than an *http.Transport use the proxy of the
+// Vouch.
+func (t *Transport) proxy() proxyFunc {
+	if b, ok := t.base().(*http.Transport); ok {
+		return b.Proxy
+	}
+	return t.Vouch.proxyFor
+}
+
+// rewind returns a clone of the request with a fresh body. It returns false
+// if the body cannot be replayed.
+func rewind(req *http.Request) (*http.Request, bool) {
+	req2 := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return req2, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	req2.Body = body
+	return req2, true
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// Client returns a copy of the base http.Client whose Transport decorates
+// every request using the Vouch. The base client's Transport is used to send
+// the decorated requests. If base is nil, an empty http.Client is used.
+//
+// If the base client has no Transport and the Vouch presents a TLS client
+// certificate, a clone of http.DefaultTransport presenting it is used; see
+// Vouch.TLSConfig.
+func (a *Vouch) Client(base *http.Client) *http.Client {
+	var c http.Client
+	if base != nil {
+		c = *base
+	}
+	if c.Transport == nil {
+		c.Transport = a.transport()
+	}
+
+	c.Transport = &Transport{
+		Vouch: a,
+		Base:  c.Transport,
+	}
+
+	return &c
+}
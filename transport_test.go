@@ -0,0 +1,363 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package vouch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/vouch/digest"
+	"github.com/xmidt-org/vouch/internal/jwt"
+	"github.com/xmidt-org/vouch/oauth"
+)
+
+func TestTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		description string
+		vouch       *Vouch
+		expectAuth  string
+		expectError bool
+	}{
+		{
+			description: "Decorates the request",
+			vouch: &Vouch{
+				insecureHosts: []string{"127.0.0.1"},
+				decorators: []decorator{
+					mockDecorator{
+						decorateFunc: func(req *http.Request) error {
+							req.Header.Set("Authorization", "Bearer token")
+							return nil
+						},
+					},
+				},
+			},
+			expectAuth: "Bearer token",
+		},
+		{
+			description: "No decorators",
+			vouch:       &Vouch{},
+		},
+		{
+			description: "Decoration fails",
+			vouch: &Vouch{
+				decorators: []decorator{
+					mockDecorator{
+						decorateFunc: func(req *http.Request) error {
+							return errors.New("failed")
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			description: "Nil Vouch",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAuth = r.Header.Get("Authorization")
+				body, _ := io.ReadAll(r.Body)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			rt := &Transport{Vouch: tc.vouch}
+
+			req, _ := http.NewRequest("POST", server.URL, strings.NewReader("hello"))
+			resp, err := rt.RoundTrip(req)
+			if tc.expectError {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != "hello" {
+				t.Errorf("expected body %q but got %q", "hello", body)
+			}
+			if gotAuth != tc.expectAuth {
+				t.Errorf("expected Authorization %q but got %q", tc.expectAuth, gotAuth)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Errorf("expected the original request to be left unmodified")
+			}
+		})
+	}
+}
+
+func TestVouch_Client(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	v := &Vouch{
+		insecureHosts: []string{"127.0.0.1"},
+		decorators: []decorator{
+			mockDecorator{
+				decorateFunc: func(req *http.Request) error {
+					req.Header.Set("Authorization", "Bearer token")
+					return nil
+				},
+			},
+		},
+	}
+
+	base := &http.Client{Timeout: time.Minute}
+	client := v.Client(base)
+
+	if client == base {
+		t.Fatalf("expected a copy of the base client")
+	}
+	if client.Timeout != base.Timeout {
+		t.Errorf("expected the base client's timeout to be kept")
+	}
+	if base.Transport != nil {
+		t.Errorf("expected the base client to be left unmodified")
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotAuth != "Bearer token" {
+		t.Errorf("expected Authorization %q but got %q", "Bearer token", gotAuth)
+	}
+
+	if v.Client(nil) == nil {
+		t.Errorf("expected a client for a nil base")
+	}
+}
+
+func TestTransport_RoundTripChallenge(t *testing.T) {
+	tests := []struct {
+		description  string
+		body         func() io.Reader
+		retry        bool
+		expectStatus int
+		expectCalls  int
+	}{
+		{
+			description:  "Retried with a new credential",
+			retry:        true,
+			expectStatus: http.StatusOK,
+			expectCalls:  2,
+		},
+		{
+			description: "Retried with a replayable body",
+			body: func() io.Reader {
+				return strings.NewReader("hello")
+			},
+			retry:        true,
+			expectStatus: http.StatusOK,
+			expectCalls:  2,
+		},
+		{
+			description: "Not retried when the body cannot be replayed",
+			body: func() io.Reader {
+				return io.NopCloser(strings.NewReader("hello"))
+			},
+			retry:        true,
+			expectStatus: http.StatusUnauthorized,
+			expectCalls:  1,
+		},
+		{
+			description:  "Not retried when no decorator handles the challenge",
+			expectStatus: http.StatusUnauthorized,
+			expectCalls:  1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				if tc.body != nil && string(body) != "hello" {
+					t.Errorf("expected body %q but got %q", "hello", body)
+				}
+				if r.Header.Get("Authorization") != "Bearer fresh" {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer server.Close()
+
+			token := "stale"
+			v := &Vouch{
+				insecureHosts: []string{"127.0.0.1", "localhost"},
+				decorators: []decorator{
+					mockChallenger{
+						mockDecorator: mockDecorator{
+							decorateFunc: func(req *http.Request) error {
+								req.Header.Set("Authorization", "Bearer "+token)
+								return nil
+							},
+						},
+						challengeFunc: func(*http.Request, *http.Response) bool {
+							token = "fresh"
+							return tc.retry
+						},
+					},
+				},
+			}
+
+			var body io.Reader
+			if tc.body != nil {
+				body = tc.body()
+			}
+			req, _ := http.NewRequest("POST", server.URL, body)
+
+			resp, err := (&Transport{Vouch: v}).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expectStatus {
+				t.Errorf("expected status %d but got %d", tc.expectStatus, resp.StatusCode)
+			}
+			if calls != tc.expectCalls {
+				t.Errorf("expected %d calls but got %d", tc.expectCalls, calls)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTripDigest(t *testing.T) {
+	h := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	param := regexp.MustCompile(`(\w+)="?([^",]*)"?`)
+
+	var calls, challenges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		p := map[string]string{}
+		for _, m := range param.FindAllStringSubmatch(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "), -1) {
+			p[m[1]] = m[2]
+		}
+
+		ha1 := h("user:devices:pass")
+		ha2 := h(r.Method + ":" + r.URL.RequestURI())
+		want := h(ha1 + ":nonce:" + p["nc"] + ":" + p["cnonce"] + ":auth:" + ha2)
+		if p["response"] != want {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="devices", nonce="nonce", qop="auth", algorithm=SHA-256`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	v, err := New(Config{
+		Digest:        digest.Config{Username: "user", Password: "pass"},
+		InsecureHosts: []string{"127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	client := v.Client(nil)
+
+	for _, path := range []string{"/first", "/second"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("did not expect an error but got: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	// Only the first request is challenged; the second one is decorated
+	// using the cached challenge.
+	if calls != 3 || challenges != 1 {
+		t.Errorf("expected 3 calls and 1 challenge but got %d and %d", calls, challenges)
+	}
+}
+
+func TestTransport_RoundTripObserve(t *testing.T) {
+	var nonces []any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token": "bound", "token_type": "DPoP", "expires_in": 3600}`))
+			return
+		}
+
+		claims, err := jwt.Claims(r.Header.Get("DPoP"))
+		if err != nil {
+			t.Errorf("did not expect an error but got: %v", err)
+		}
+		nonces = append(nonces, claims["nonce"])
+		w.Header().Set("DPoP-Nonce", "nonce-"+strconv.Itoa(len(nonces)))
+	}))
+	defer server.Close()
+
+	v, err := New(Config{
+		OAuth: oauth.Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			TokenURL:     server.URL + "/token",
+			DPoP:         true,
+		},
+		InsecureHosts: []string{"127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("did not expect an error but got: %v", err)
+	}
+	client := v.Client(nil)
+
+	for range 3 {
+		resp, err := client.Get(server.URL + "/things")
+		if err != nil {
+			t.Fatalf("did not expect an error but got: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	// Each proof carries the nonce of the previous successful response.
+	expected := []any{nil, "nonce-1", "nonce-2"}
+	if !reflect.DeepEqual(nonces, expected) {
+		t.Errorf("expected nonces %v but got %v", expected, nonces)
+	}
+}
+
+type mockChallenger struct {
+	mockDecorator
+	challengeFunc func(*http.Request, *http.Response) bool
+}
+
+func (m mockChallenger) Challenge(req *http.Request, resp *http.Response) bool {
+	return m.challengeFunc(req, resp)
+}